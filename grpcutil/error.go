@@ -84,6 +84,11 @@ func (e grpcError) GRPCStatus() *status.Status {
 	return status.New(e.code, msg)
 }
 
+// GRPCCode returns the status code's name for logutil.ErrorLinks.
+func (e grpcError) GRPCCode() string {
+	return e.code.String()
+}
+
 // GRPCPublicStatus returns the status that's passed to lower levels by obfuscateError.
 func (e grpcError) GRPCPublicStatus(ctx context.Context) *status.Status {
 	st := status.New(e.code, string(e.msg))
@@ -16,25 +16,44 @@ import (
 	"google.golang.org/protobuf/proto"
 )
 
+// UnaryServerLogContextInterceptor attaches log to the call's context. Debug logs are retained in
+// a logutil.RequestBuffer and logged only if the call fails.
 func UnaryServerLogContextInterceptor(log *slog.Logger) grpc.UnaryServerInterceptor {
 	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
-		ctx = logutil.WithLogContext(ctx, log)
-		ctx = context.WithValue(ctx, accessLogTag{}, &accessLog{})
-		return handler(ctx, req)
+		ctx, opts := withLogContext(ctx, log)
+		resp, err := handler(ctx, req)
+		finishLogBuffer(opts.Buffer, err)
+		return resp, err
 	}
 }
 
+// StreamServerLogContextInterceptor is the streaming equivalent of UnaryServerLogContextInterceptor.
 func StreamServerLogContextInterceptor(log *slog.Logger) grpc.StreamServerInterceptor {
 	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
-		ctx := stream.Context()
-		ctx = logutil.WithLogContext(ctx, log)
-		ctx = context.WithValue(ctx, accessLogTag{}, &accessLog{})
+		ctx, opts := withLogContext(stream.Context(), log)
 		wrapped := middleware.WrapServerStream(stream)
 		wrapped.WrappedContext = ctx
-		return handler(srv, wrapped)
+		err := handler(srv, wrapped)
+		finishLogBuffer(opts.Buffer, err)
+		return err
 	}
 }
 
+func withLogContext(ctx context.Context, log *slog.Logger) (context.Context, *accessLog) {
+	opts := &accessLog{}
+	log, opts.Buffer = logutil.NewRequestBuffer(log)
+	ctx = logutil.WithLogContext(ctx, log)
+	ctx = context.WithValue(ctx, accessLogTag{}, opts)
+	return ctx, opts
+}
+
+func finishLogBuffer(buf *logutil.RequestBuffer, err error) {
+	if err != nil && !errors.Is(err, context.Canceled) {
+		buf.Flush() // usually done already by logHandler, before the call's log line
+	}
+	buf.Discard()
+}
+
 func UnaryServerLogWriterInterceptor() grpc.UnaryServerInterceptor {
 	return logging.UnaryServerInterceptor(&logHandler{},
 		logging.WithCodes(errToCode),
@@ -69,6 +88,15 @@ type accessLogTag struct{}
 type accessLog struct {
 	SuppressInfoLog bool
 	User            *logutil.UserValue
+	Buffer          *logutil.RequestBuffer
+}
+
+// FlushLogBuffer logs the call's buffered debug logs, see logutil.NewRequestBuffer. This is done
+// automatically when the call fails or logs at ERROR level.
+func FlushLogBuffer(ctx context.Context) {
+	if p, ok := ctx.Value(accessLogTag{}).(*accessLog); ok {
+		p.Buffer.Flush()
+	}
 }
 
 // DisableAccessLog suppresses informational access log lines for the request.
@@ -106,12 +134,29 @@ func (*logHandler) Log(ctx context.Context, levelInt logging.Level, msg string,
 		if user := p.User; user != nil {
 			log = log.With(slog.Any(logutil.UserKey, *user))
 		}
+		if hasErrorField(fields) {
+			p.Buffer.Flush() // before the call's log line, so that records appear in order
+		}
 	}
 	f := (*logging.Fields)(&fields)
 	f.Delete("grpc.error") // stringified duplicate of errToFields's proper error field
 	log.Log(ctx, level, msg, fields...)
 }
 
+func hasErrorField(fields []any) bool {
+	for i := 0; i+1 < len(fields); i += 2 {
+		if fields[i] == logutil.ErrKey {
+			value := fields[i+1]
+			if v, ok := value.(slog.Value); ok {
+				value = v.Resolve().Any() // as set by errToFields
+			}
+			err, ok := value.(error)
+			return ok && !errors.Is(err, context.Canceled)
+		}
+	}
+	return false
+}
+
 func LogAttr(key string, m interface {
 	proto.Message
 	fmt.Stringer
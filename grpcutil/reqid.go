@@ -25,12 +25,18 @@ func StreamServerRequestIdInterceptor() grpc.StreamServerInterceptor {
 	}
 }
 
+// attachRequestID attaches a request ID, and continues the caller's W3C trace, see
+// tracectx.Continue. Its IDs are logged via logutil.ContextAttrs.
 func attachRequestID(ctx context.Context) context.Context {
 	id := uuid.NewString()
-	log := logutil.FromContext(ctx).With(slog.String("request_id", id))
-	ctx = logutil.WithLogContext(ctx, log)
+	idAttr := slog.String(logutil.RequestIDKey, id)
 	md, ok := metadata.FromIncomingContext(ctx)
+	ctx = continueTrace(ctx, md)
+	log := logutil.FromContext(ctx).With(idAttr)
+	ctx = logutil.WithLogContext(ctx, log)
+	ctx = logutil.WithContextAttrs(ctx, idAttr)
 	if ok {
+		md = md.Copy()
 		md.Set("request-id", id)
 		ctx = metadata.NewIncomingContext(ctx, md)
 	}
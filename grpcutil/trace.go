@@ -0,0 +1,50 @@
+package grpcutil
+
+import (
+	"context"
+	"strings"
+
+	"github.com/authenticvision/util-go/tracectx"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// UnaryClientTraceInterceptor propagates the W3C trace of the call's context to the server via
+// metadata, see tracectx.
+func UnaryClientTraceInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(injectTrace(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientTraceInterceptor is the streaming equivalent of UnaryClientTraceInterceptor.
+func StreamClientTraceInterceptor() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(injectTrace(ctx), desc, cc, method, opts...)
+	}
+}
+
+func injectTrace(ctx context.Context) context.Context {
+	span, ok := tracectx.FromContext(ctx)
+	if !ok || !span.IsValid() {
+		return ctx
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	if len(md.Get(tracectx.TraceparentHeader)) != 0 {
+		return ctx // set explicitly by the caller
+	}
+	md = md.Copy()
+	md.Set(tracectx.TraceparentHeader, span.Traceparent())
+	if span.State != "" {
+		md.Set(tracectx.TracestateHeader, span.State)
+	}
+	return metadata.NewOutgoingContext(ctx, md)
+}
+
+func continueTrace(ctx context.Context, md metadata.MD) context.Context {
+	var traceparent string
+	if values := md.Get(tracectx.TraceparentHeader); len(values) != 0 {
+		traceparent = values[0]
+	}
+	return tracectx.Continue(ctx, traceparent, strings.Join(md.Get(tracectx.TracestateHeader), ","))
+}
@@ -0,0 +1,52 @@
+package grpcutil
+
+import (
+	"context"
+	"testing"
+
+	"github.com/authenticvision/util-go/tracectx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTraceRoundTrip(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	span := tracectx.New()
+	span.State = "vendor=value"
+	ctx := metadata.AppendToOutgoingContext(t.Context(), "other", "kept")
+	ctx = injectTrace(tracectx.WithSpanContext(ctx, span))
+
+	// the client's outgoing metadata is the server's incoming metadata
+	md, ok := metadata.FromOutgoingContext(ctx)
+	r.True(ok)
+	a.Equal([]string{"kept"}, md.Get("other"))
+	child, ok := tracectx.FromContext(continueTrace(context.Background(), md))
+	r.True(ok)
+	a.Equal(span.TraceID, child.TraceID)
+	a.NotEqual(span.SpanID, child.SpanID)
+	a.Equal(span.State, child.State)
+}
+
+func TestInjectTrace(t *testing.T) {
+	a := assert.New(t)
+
+	// no span, nothing to propagate
+	ctx := injectTrace(t.Context())
+	_, ok := metadata.FromOutgoingContext(ctx)
+	a.False(ok)
+
+	// explicit traceparent of the caller is kept
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	ctx = metadata.AppendToOutgoingContext(t.Context(), tracectx.TraceparentHeader, traceparent)
+	ctx = injectTrace(tracectx.WithSpanContext(ctx, tracectx.New()))
+	md, _ := metadata.FromOutgoingContext(ctx)
+	a.Equal([]string{traceparent}, md.Get(tracectx.TraceparentHeader))
+
+	// without incoming metadata, a new trace is started
+	span, ok := tracectx.FromContext(continueTrace(t.Context(), nil))
+	a.True(ok)
+	a.True(span.IsValid())
+}
@@ -0,0 +1,249 @@
+// Package health implements liveness and readiness probes. Components register named checks, e.g.
+// a database ping, and the Livez and Readyz handlers report their aggregated status as JSON.
+package health
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/authenticvision/util-go/httpmw"
+	"github.com/authenticvision/util-go/httpp"
+	"github.com/authenticvision/util-go/logutil"
+)
+
+// DefaultTimeout limits the duration of checks that have no Timeout.
+var DefaultTimeout = 5 * time.Second
+
+// CacheTTL is the duration for which a check's result is reused. This bounds the load that
+// frequent probes from multiple sources put onto dependencies.
+var CacheTTL = time.Second
+
+// Check is a named health check.
+type Check struct {
+	Name string
+
+	// Func reports an unhealthy dependency by returning an error. It must honor ctx's deadline.
+	Func func(ctx context.Context) error
+
+	// Timeout defaults to DefaultTimeout.
+	Timeout time.Duration
+
+	// Critical checks fail readiness. Failing non-critical checks only degrade the reported status.
+	Critical bool
+
+	// Liveness checks are evaluated by Livez in addition to Readyz. A failing liveness probe makes
+	// Kubernetes restart the container, so only use this for states that cannot recover on their own.
+	Liveness bool
+}
+
+type Status string
+
+const (
+	StatusOK           Status = "ok"
+	StatusDegraded     Status = "degraded"
+	StatusFailing      Status = "failing"
+	StatusShuttingDown Status = "shutting_down"
+)
+
+// Report is the aggregated result of all checks of a probe.
+type Report struct {
+	Status Status                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks,omitempty"`
+}
+
+// CheckResult is the result of a single check. Errors are logged, but not reported, because they
+// might contain confidential data such as connection strings.
+type CheckResult struct {
+	Status     Status    `json:"status"`
+	Critical   bool      `json:"critical"`
+	CheckedAt  time.Time `json:"checked_at"`
+	DurationMS int64     `json:"duration_ms"`
+}
+
+// Registry holds health checks. The zero value is ready to use.
+type Registry struct {
+	mu           sync.RWMutex
+	checks       []*checkState
+	shuttingDown atomic.Bool
+}
+
+// Default is the registry used by the package-level functions and handlers.
+var Default = new(Registry)
+
+// Register adds a check to the Default registry.
+func Register(check Check) {
+	Default.Register(check)
+}
+
+// BeginShutdown makes readiness of the Default registry fail. It is called by
+// mainutil.ListenAndServe when shutdown begins, so that load balancers stop sending new requests.
+func BeginShutdown() {
+	Default.BeginShutdown()
+}
+
+// Livez and Readyz serve probes of the Default registry.
+var (
+	Livez  = Default.LivezHandler()
+	Readyz = Default.ReadyzHandler()
+)
+
+// Register adds a check. It panics if the check has no name or function, or if its name is taken.
+func (reg *Registry) Register(check Check) {
+	if check.Name == "" || check.Func == nil {
+		panic("health.Register: check requires name and function")
+	}
+	if check.Timeout <= 0 {
+		check.Timeout = DefaultTimeout
+	}
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for _, c := range reg.checks {
+		if c.check.Name == check.Name {
+			panic(fmt.Sprintf("health.Register: duplicate check %q", check.Name))
+		}
+	}
+	reg.checks = append(reg.checks, &checkState{check: check, healthy: true})
+}
+
+// BeginShutdown makes all subsequent readiness probes fail. It cannot be undone.
+func (reg *Registry) BeginShutdown() {
+	reg.shuttingDown.Store(true)
+}
+
+// Live evaluates liveness checks.
+func (reg *Registry) Live(ctx context.Context) Report {
+	return reg.evaluate(ctx, func(c Check) bool { return c.Liveness })
+}
+
+// Ready evaluates all checks, unless shutdown began.
+func (reg *Registry) Ready(ctx context.Context) Report {
+	if reg.shuttingDown.Load() {
+		return Report{Status: StatusShuttingDown}
+	}
+	return reg.evaluate(ctx, func(Check) bool { return true })
+}
+
+// LivezHandler serves Live as JSON, with status 503 if a liveness check fails.
+func (reg *Registry) LivezHandler() httpp.Handler {
+	return probeHandler(reg.Live)
+}
+
+// ReadyzHandler serves Ready as JSON, with status 503 if a critical check fails or shutdown began.
+func (reg *Registry) ReadyzHandler() httpp.Handler {
+	return probeHandler(reg.Ready)
+}
+
+func probeHandler(probe func(context.Context) Report) httpp.Handler {
+	return httpp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		// Probes are frequent, see buildinfo.Handler.
+		httpmw.DisableAccessLog(r)
+
+		report := probe(r.Context())
+		statusCode := http.StatusOK
+		if report.Status == StatusFailing || report.Status == StatusShuttingDown {
+			statusCode = http.StatusServiceUnavailable
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		return httpp.JSONStatus(w, report, statusCode)
+	})
+}
+
+func (reg *Registry) evaluate(ctx context.Context, include func(Check) bool) Report {
+	reg.mu.RLock()
+	var checks []*checkState
+	for _, c := range reg.checks {
+		if include(c.check) {
+			checks = append(checks, c)
+		}
+	}
+	reg.mu.RUnlock()
+
+	results := make([]CheckResult, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Go(func() {
+			results[i] = c.result(ctx)
+		})
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusOK, Checks: make(map[string]CheckResult, len(checks))}
+	for i, c := range checks {
+		result := results[i]
+		report.Checks[c.check.Name] = result
+		if result.Status != StatusOK {
+			if c.check.Critical || c.check.Liveness {
+				report.Status = StatusFailing
+			} else if report.Status == StatusOK {
+				report.Status = StatusDegraded
+			}
+		}
+	}
+	return report
+}
+
+type checkState struct {
+	check Check
+
+	mu      sync.Mutex // serializes runs, so that concurrent probes share a result
+	last    CheckResult
+	healthy bool // for logging transitions only
+}
+
+func (c *checkState) result(ctx context.Context) CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.last.CheckedAt.IsZero() && time.Since(c.last.CheckedAt) < CacheTTL {
+		return c.last
+	}
+
+	start := time.Now()
+	err := c.run(ctx)
+	c.last = CheckResult{
+		Status:     StatusOK,
+		Critical:   c.check.Critical,
+		CheckedAt:  start,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		c.last.Status = StatusFailing
+	}
+
+	// Log transitions only, probes would otherwise flood the log.
+	log := logutil.FromContext(ctx)
+	if err != nil && c.healthy {
+		log.WarnContext(ctx, "health check failed", slog.String("check", c.check.Name), logutil.Err(err))
+	} else if err == nil && !c.healthy {
+		log.InfoContext(ctx, "health check recovered", slog.String("check", c.check.Name))
+	}
+	c.healthy = err == nil
+
+	return c.last
+}
+
+func (c *checkState) run(ctx context.Context) error {
+	// A canceled probe request must not be cached as a failing dependency.
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.check.Timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if v := recover(); v != nil {
+				done <- fmt.Errorf("panic in health check: %v", v)
+			}
+		}()
+		done <- c.check.Func(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("health check timed out after %s: %w", c.check.Timeout, context.Cause(ctx))
+	}
+}
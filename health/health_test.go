@@ -0,0 +1,134 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry_Ready(t *testing.T) {
+	errDown := errors.New("down")
+	tests := []struct {
+		name       string
+		checks     []Check
+		wantStatus Status
+		wantCode   int
+	}{
+		{name: "no checks", wantStatus: StatusOK, wantCode: http.StatusOK},
+		{
+			name: "all ok",
+			checks: []Check{
+				{Name: "db", Func: func(context.Context) error { return nil }, Critical: true},
+			},
+			wantStatus: StatusOK,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name: "non-critical failing",
+			checks: []Check{
+				{Name: "db", Func: func(context.Context) error { return nil }, Critical: true},
+				{Name: "cache", Func: func(context.Context) error { return errDown }},
+			},
+			wantStatus: StatusDegraded,
+			wantCode:   http.StatusOK,
+		},
+		{
+			name: "critical failing",
+			checks: []Check{
+				{Name: "db", Func: func(context.Context) error { return errDown }, Critical: true},
+				{Name: "cache", Func: func(context.Context) error { return nil }},
+			},
+			wantStatus: StatusFailing,
+			wantCode:   http.StatusServiceUnavailable,
+		},
+		{
+			name: "critical timeout",
+			checks: []Check{
+				{Name: "db", Func: func(ctx context.Context) error {
+					<-ctx.Done()
+					return ctx.Err()
+				}, Timeout: 10 * time.Millisecond, Critical: true},
+			},
+			wantStatus: StatusFailing,
+			wantCode:   http.StatusServiceUnavailable,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := require.New(t)
+			a := assert.New(t)
+
+			var reg Registry
+			for _, check := range tt.checks {
+				reg.Register(check)
+			}
+			w := httptest.NewRecorder()
+			r.NoError(reg.ReadyzHandler().ServeErrHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil)))
+			a.Equal(tt.wantCode, w.Code)
+
+			var report Report
+			r.NoError(json.Unmarshal(w.Body.Bytes(), &report))
+			a.Equal(tt.wantStatus, report.Status)
+			a.Len(report.Checks, len(tt.checks))
+		})
+	}
+}
+
+func TestRegistry_Live(t *testing.T) {
+	a := assert.New(t)
+
+	var reg Registry
+	reg.Register(Check{Name: "db", Func: func(context.Context) error { return errors.New("down") }, Critical: true})
+	report := reg.Live(t.Context())
+	a.Equal(StatusOK, report.Status)
+	a.Empty(report.Checks)
+
+	reg.Register(Check{Name: "loop", Func: func(context.Context) error { return errors.New("stuck") }, Liveness: true})
+	report = reg.Live(t.Context())
+	a.Equal(StatusFailing, report.Status)
+	a.Contains(report.Checks, "loop")
+	a.NotContains(report.Checks, "db")
+}
+
+func TestRegistry_Cache(t *testing.T) {
+	a := assert.New(t)
+
+	var calls atomic.Int32
+	var reg Registry
+	reg.Register(Check{Name: "db", Func: func(context.Context) error {
+		calls.Add(1)
+		return nil
+	}})
+	reg.Ready(t.Context())
+	reg.Ready(t.Context())
+	a.Equal(int32(1), calls.Load())
+}
+
+func TestRegistry_BeginShutdown(t *testing.T) {
+	a := assert.New(t)
+
+	var reg Registry
+	reg.Register(Check{Name: "db", Func: func(context.Context) error { return nil }, Critical: true})
+	a.Equal(StatusOK, reg.Ready(t.Context()).Status)
+
+	reg.BeginShutdown()
+	a.Equal(StatusShuttingDown, reg.Ready(t.Context()).Status)
+	a.Equal(StatusOK, reg.Live(t.Context()).Status)
+}
+
+func TestRegistry_Register(t *testing.T) {
+	a := assert.New(t)
+
+	var reg Registry
+	reg.Register(Check{Name: "db", Func: func(context.Context) error { return nil }})
+	a.Panics(func() { reg.Register(Check{Name: "db", Func: func(context.Context) error { return nil }}) })
+	a.Panics(func() { reg.Register(Check{Name: "nil"}) })
+}
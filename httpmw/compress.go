@@ -77,6 +77,7 @@ func (h *compressHandler) ServeErrHTTP(w http.ResponseWriter, r *http.Request) e
 }
 
 func (h *compressHandler) serveEncoded(w http.ResponseWriter, r *http.Request, codec compressCodec) (result error) {
+	r = stripETagSuffixes(r, codec.name)
 	cw := &compressWriter{
 		ResponseWriter: w,
 		compressCodec:  codec,
@@ -126,12 +127,21 @@ func (w *compressWriter) WriteHeader(statusCode int) {
 	case hdr.Get("Content-Encoding") != "":
 		// already compressed
 
+	case statusCode == http.StatusNotModified:
+		// no body, but the ETag must match the one of the compressed 200 response
+		addETagSuffix(hdr, w.name)
+		addVary(hdr, "Accept-Encoding")
+
+	case statusCode < 200 || statusCode == http.StatusNoContent:
+		// no body
+
 	case hdr.Get("Content-Length") != "":
 		// respect the handler's wish to send a fixed-length response as-is
 
 	default:
 		hdr.Set("Content-Encoding", w.name)
 		addVary(hdr, "Accept-Encoding")
+		addETagSuffix(hdr, w.name)
 		w.encoder = w.pool.Get().(resettableWriter)
 		w.encoder.Reset(w.ResponseWriter)
 	}
@@ -211,3 +221,35 @@ func addVary(hdr http.Header, value string) {
 		hdr.Set("Vary", value)
 	}
 }
+
+// addETagSuffix derives a distinct ETag for the encoded representation, as required by RFC 9110
+// section 8.8.3. Clients send it back in conditional requests, see stripETagSuffixes.
+func addETagSuffix(hdr http.Header, codecName string) {
+	if etag := hdr.Get("ETag"); strings.HasSuffix(etag, `"`) {
+		hdr.Set("ETag", etag[:len(etag)-1]+"-"+codecName+`"`)
+	}
+}
+
+// stripETagSuffixes reverts addETagSuffix for conditional request headers, so that handlers can
+// compare them against the ETag of the unencoded representation.
+func stripETagSuffixes(r *http.Request, codecName string) *http.Request {
+	suffix := "-" + codecName + `"`
+	var hdr http.Header
+	for _, key := range []string{"If-Match", "If-None-Match"} {
+		value := r.Header.Get(key)
+		if !strings.Contains(value, suffix) {
+			continue
+		}
+		if hdr == nil {
+			hdr = r.Header.Clone()
+		}
+		hdr.Set(key, strings.ReplaceAll(value, suffix, `"`))
+	}
+	if hdr == nil {
+		return r
+	}
+	r2 := new(http.Request)
+	*r2 = *r
+	r2.Header = hdr
+	return r2
+}
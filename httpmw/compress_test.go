@@ -0,0 +1,51 @@
+package httpmw
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/authenticvision/util-go/httpp"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompression_FlushSSE(t *testing.T) {
+	r := require.New(t)
+
+	sent := make(chan struct{})
+	release := make(chan struct{})
+	server := httptest.NewServer(httpp.NeverErrors(Chain(httpp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return httpp.ServeSSE(w, r, func(stream *httpp.EventStream) error {
+			if err := stream.SendJSON("progress", "1", map[string]int{"done": 1}); err != nil {
+				return err
+			}
+			close(sent)
+			<-release
+			return nil
+		})
+	}), NewCompressionMiddleware())))
+	defer server.Close()
+	defer close(release)
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	r.NoError(err)
+	req.Header.Set("Accept-Encoding", "zstd")
+	resp, err := http.DefaultClient.Do(req)
+	r.NoError(err)
+	defer func() { _ = resp.Body.Close() }()
+	r.Equal("zstd", resp.Header.Get("Content-Encoding"))
+	r.Equal("text/event-stream", resp.Header.Get("Content-Type"))
+
+	<-sent
+	dec, err := zstd.NewReader(resp.Body)
+	r.NoError(err)
+	defer dec.Close()
+	lines := bufio.NewScanner(dec)
+	var got []string
+	for len(got) < 3 && lines.Scan() {
+		got = append(got, lines.Text())
+	}
+	r.Equal([]string{"event: progress", "id: 1", `data: {"done":1}`}, got)
+}
@@ -1,14 +1,20 @@
 package httpmw
 
 import (
+	"log/slog"
 	"net/http"
 
 	"github.com/authenticvision/util-go/httpp"
+	"github.com/authenticvision/util-go/logutil"
 )
 
 // NewCrossOriginProtection is the httpp equivalent to http.NewCrossOriginProtection.
+// Rejected requests are returned as 403 error, so that they are logged and rendered like any
+// other handler error. This can be overridden via SetDenyHandler.
 func NewCrossOriginProtection() *CrossOriginProtection {
-	return &CrossOriginProtection{}
+	c := &CrossOriginProtection{}
+	c.SetDenyHandler(httpp.CollectErrors(httpp.HandlerFunc(c.deny)))
+	return c
 }
 
 // CrossOriginProtection wraps http.CrossOriginProtection as Middleware.
@@ -24,3 +30,9 @@ func (c *CrossOriginProtection) Middleware(handler httpp.Handler) httpp.Handler
 		return *err
 	})
 }
+
+func (c *CrossOriginProtection) deny(_ http.ResponseWriter, r *http.Request) error {
+	// http.CrossOriginProtection does not pass the reason to the deny handler, so check again
+	err := c.Check(r)
+	return logutil.Severity(httpp.Err(err, http.StatusForbidden, "cross-origin request rejected"), slog.LevelWarn)
+}
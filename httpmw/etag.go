@@ -0,0 +1,133 @@
+package httpmw
+
+import (
+	"bytes"
+	"log/slog"
+	"net/http"
+
+	"github.com/authenticvision/util-go/bsize"
+	"github.com/authenticvision/util-go/httpp"
+	"github.com/authenticvision/util-go/logutil"
+)
+
+// ETagMaxBuffer is the maximum response size for which NewETagMiddleware computes an ETag.
+// Larger responses are passed on as-is.
+var ETagMaxBuffer = 4 * bsize.MiB
+
+// NewETagMiddleware buffers successful responses to GET and HEAD requests, tags them with an
+// ETag computed via etag, and answers conditional requests via httpp.CheckPreconditions.
+// Handlers can supply their own ETag and Last-Modified headers instead. Responses are passed on
+// as-is when the handler flushes, i.e. for streaming responses, or exceeds ETagMaxBuffer.
+// The middleware must be added before NewCompressionMiddleware, i.e. as inner middleware, so that
+// compression can derive a distinct ETag for each Content-Encoding.
+func NewETagMiddleware(etag httpp.ETagFunc) Middleware {
+	return &etagMiddleware{etag: etag}
+}
+
+type etagMiddleware struct {
+	etag httpp.ETagFunc
+}
+
+func (m *etagMiddleware) Middleware(next httpp.Handler) httpp.Handler {
+	return httpp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			return next.ServeErrHTTP(w, r)
+		}
+		ew := &etagWriter{ResponseWriter: w}
+		if err := next.ServeErrHTTP(ew, r); err != nil {
+			// handler errors are rendered by an outer middleware, discard the partial response
+			if !ew.passThrough {
+				ew.buf.Reset()
+			}
+			return err
+		}
+		if ew.passThrough {
+			return nil
+		}
+		return ew.finish(r, m.etag)
+	})
+}
+
+var _ interface {
+	http.ResponseWriter
+	httpp.ResponseWriterUnwrapper
+} = &etagWriter{}
+
+// etagWriter buffers a response until the handler returns, unless it switches to pass-through.
+type etagWriter struct {
+	http.ResponseWriter
+	statusCode  int
+	buf         bytes.Buffer
+	passThrough bool
+}
+
+func (w *etagWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *etagWriter) WriteHeader(statusCode int) {
+	if w.passThrough || w.statusCode != 0 {
+		w.ResponseWriter.WriteHeader(statusCode)
+		return
+	}
+	w.statusCode = statusCode
+	if statusCode != http.StatusOK {
+		w.startPassThrough()
+	}
+}
+
+func (w *etagWriter) Write(b []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passThrough {
+		return w.ResponseWriter.Write(b)
+	}
+	if w.buf.Len()+len(b) > int(ETagMaxBuffer) {
+		if err := w.startPassThrough(); err != nil {
+			return 0, err
+		}
+		return w.ResponseWriter.Write(b)
+	}
+	return w.buf.Write(b)
+}
+
+// FlushError is called via http.ResponseController and indicates a streaming response.
+func (w *etagWriter) FlushError() error {
+	if w.statusCode == 0 {
+		w.WriteHeader(http.StatusOK)
+	}
+	if err := w.startPassThrough(); err != nil {
+		return err
+	}
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *etagWriter) startPassThrough() error {
+	if w.passThrough {
+		return nil
+	}
+	w.passThrough = true
+	w.ResponseWriter.WriteHeader(w.statusCode)
+	_, err := w.ResponseWriter.Write(w.buf.Bytes())
+	w.buf = bytes.Buffer{}
+	return err
+}
+
+func (w *etagWriter) finish(r *http.Request, etag httpp.ETagFunc) error {
+	hdr := w.Header()
+	if hdr.Get("ETag") == "" {
+		hdr.Set("ETag", etag(w.buf.Bytes()))
+	}
+	if done, err := httpp.CheckPreconditions(w.ResponseWriter, r); done || err != nil {
+		return err
+	}
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK // handler wrote nothing
+	}
+	if err := w.startPassThrough(); err != nil {
+		// This usually fails with an I/O error when the client disconnects unexpectedly.
+		return logutil.Severity(httpp.ServerError(err, "ETag response write failure"), slog.LevelWarn)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package httpmw
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/authenticvision/util-go/httpp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestETagMiddleware_Compression(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	handler := Chain(httpp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		return httpp.JSON(w, map[string]string{"hello": "world"})
+	}), NewETagMiddleware(httpp.StrongETag), NewCompressionMiddleware())
+
+	get := func(encoding, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if encoding != "" {
+			req.Header.Set("Accept-Encoding", encoding)
+		}
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		r.NoError(handler.ServeErrHTTP(w, req))
+		return w
+	}
+
+	plain := get("", "")
+	a.Equal(http.StatusOK, plain.Code)
+	plainETag := plain.Header().Get("ETag")
+	r.NotEmpty(plainETag)
+
+	zstd := get("zstd", "")
+	a.Equal("zstd", zstd.Header().Get("Content-Encoding"))
+	zstdETag := zstd.Header().Get("ETag")
+	a.Equal(plainETag[:len(plainETag)-1]+`-zstd"`, zstdETag)
+
+	notModified := get("zstd", zstdETag)
+	a.Equal(http.StatusNotModified, notModified.Code)
+	a.Equal(zstdETag, notModified.Header().Get("ETag"))
+	a.Empty(notModified.Header().Get("Content-Encoding"))
+	a.Empty(notModified.Body.Bytes())
+
+	a.Equal(http.StatusNotModified, get("", plainETag).Code)
+	a.Equal(http.StatusOK, get("gzip", zstdETag).Code)
+}
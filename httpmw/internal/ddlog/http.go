@@ -9,6 +9,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"github.com/authenticvision/util-go/logutil"
 	"github.com/google/uuid"
 )
 
@@ -100,6 +101,9 @@ func urlDetailsFromRequest(r *http.Request) (result URLDetails) {
 				// In case of duplicates, only the first value is logged. This is consistent with
 				// the implementation of http.Value.Get, which the application is likely to use.
 				result.QueryString[k] = v[0]
+				if logutil.RedactKey(k) {
+					result.QueryString[k] = logutil.Redacted
+				}
 			}
 		}
 	}
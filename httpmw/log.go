@@ -11,6 +11,7 @@ import (
 	"github.com/authenticvision/util-go/httpmw/internal/ddlog"
 	"github.com/authenticvision/util-go/httpp"
 	"github.com/authenticvision/util-go/logutil"
+	"github.com/authenticvision/util-go/tracectx"
 	"github.com/google/uuid"
 )
 
@@ -21,6 +22,7 @@ type accessLogTag struct{}
 type accessLog struct {
 	SuppressInfoLog bool
 	User            *User
+	Buffer          *logutil.RequestBuffer
 }
 
 // WithRequestUser attaches the given user identity to the request's log, and
@@ -35,12 +37,22 @@ func WithRequestUser(r *http.Request, user User) *http.Request {
 	return r.WithContext(logutil.WithLogContext(ctx, log))
 }
 
-// NewLogMiddleware creates a middleware for recording each request as log line.
-// Errors are processed via logutil.Destructure and won't be forwarded.
+// NewLogMiddleware creates a middleware for recording each request as log line. Each request
+// continues the caller's W3C trace, see tracectx.FromHTTP, and is logged with its trace ID.
+// Errors are processed via logutil.Destructure and won't be forwarded. They are written to the
+// client through the request's httpp.ErrorRenderer, see NewErrorRendererMiddleware.
 func NewLogMiddleware(log *slog.Logger) Middleware {
 	return &logMiddleware{log: log}
 }
 
+// FlushLogBuffer logs the request's buffered debug logs, see logutil.NewRequestBuffer. This is
+// done automatically when the handler returns an error or logs at ERROR level.
+func FlushLogBuffer(r *http.Request) {
+	if p, ok := r.Context().Value(accessLogTag{}).(*accessLog); ok {
+		p.Buffer.Flush()
+	}
+}
+
 // DisableAccessLog suppresses informational access log lines for the request.
 // This only affects the application's internal access log.
 func DisableAccessLog(r *http.Request) {
@@ -65,11 +77,17 @@ type logHandler struct {
 func (h *logHandler) ServeErrHTTP(w http.ResponseWriter, r *http.Request) error {
 	// public random ID for all log lines of this request, e.g. for use on error screens
 	id := uuid.New()
-	w.Header().Set("X-Request-Id", id.String())
+	w.Header().Set(httpp.RequestIDHeader, id.String())
+
+	// continue the caller's trace, or start a new one
+	ctx := tracectx.FromHTTP(r.Context(), r.Header)
 
 	// attach logger and extendable scope to context
 	var opts accessLog
-	ctx := logutil.WithLogContext(r.Context(), ddlog.WithRequest(h.log, r, id))
+	var reqLog *slog.Logger
+	reqLog, opts.Buffer = logutil.NewRequestBuffer(ddlog.WithRequest(h.log, r, id))
+	ctx = logutil.WithLogContext(ctx, reqLog)
+	ctx = logutil.WithContextAttrs(ctx, slog.String(logutil.RequestIDKey, id.String()))
 	ctx = context.WithValue(ctx, accessLogTag{}, &opts)
 	r = r.WithContext(ctx)
 
@@ -78,10 +96,12 @@ func (h *logHandler) ServeErrHTTP(w http.ResponseWriter, r *http.Request) error
 	start := time.Now()
 	err := h.next.ServeErrHTTP(hookedW, r)
 	duration := time.Since(start)
-	if err != nil {
-		httpp.WriteError(hookedW, err)
+	if err != nil && !hookedW.wroteHeader {
+		httpp.RenderError(hookedW, r, err)
 		// hookedW.statusCode is available now in case of errors
 	}
+	// Otherwise the handler failed mid-response, e.g. while streaming. The status code was already
+	// sent, so the error is only logged.
 
 	// attach request+response telemetry
 	log := h.log.With(slog.Duration("duration", duration))
@@ -104,12 +124,15 @@ func (h *logHandler) ServeErrHTTP(w http.ResponseWriter, r *http.Request) error
 			// under our influence, hence always log it with info level.
 		} else if errors.As(err, &errLeveler) {
 			level = errLeveler.Level()
+			opts.Buffer.Flush()
 		} else {
 			level = slog.LevelError
+			opts.Buffer.Flush()
 		}
 
 		log = log.With(logutil.Err(err))
 	}
+	opts.Buffer.Discard() // stop retaining, e.g. for goroutines that outlive the request
 
 	if !opts.SuppressInfoLog || level != slog.LevelInfo {
 		log.Log(ctx, level, "HTTP request")
@@ -1,12 +1,21 @@
 package httpmw
 
 import (
+	"bytes"
 	"context"
+	"errors"
+	"log/slog"
 	"net"
 	"net/http"
+	"net/http/httptest"
+	"strings"
 	"syscall"
 	"testing"
 
+	"github.com/authenticvision/util-go/httpp"
+	"github.com/authenticvision/util-go/logutil"
+	"github.com/authenticvision/util-go/tracectx"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -66,3 +75,66 @@ func synthesizeEPIPE(t *testing.T, r *require.Assertions) (error, *http.Request)
 	r.ErrorIs(err, syscall.EPIPE)
 	return err, req
 }
+
+func TestLogMiddleware_RequestBuffer(t *testing.T) {
+	prev := logutil.RequestBufferSize
+	t.Cleanup(func() { logutil.RequestBufferSize = prev })
+	logutil.RequestBufferSize = 10
+
+	tests := []struct {
+		name      string
+		err       error
+		wantDebug bool
+	}{
+		{name: "success"},
+		{name: "client error", err: httpp.BadRequest(nil, "bad"), wantDebug: true},
+		{name: "server error", err: errors.New("boom"), wantDebug: true},
+		{name: "canceled", err: context.Canceled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+
+			out := bytes.NewBuffer(nil)
+			h, err := logutil.NewHandlerTo(out, logutil.FormatJSON, slog.LevelInfo)
+			require.NoError(t, err)
+			handler := Chain(httpp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+				logutil.FromContext(r.Context()).Debug("handler detail")
+				return tt.err
+			}), NewLogMiddleware(slog.New(h)))
+			a.NoError(handler.ServeErrHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil)))
+
+			a.Equal(tt.wantDebug, strings.Contains(out.String(), "handler detail"))
+			a.Contains(out.String(), "HTTP request")
+		})
+	}
+}
+
+func TestLogMiddleware_Trace(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	out := bytes.NewBuffer(nil)
+	h, err := logutil.NewHandlerTo(out, logutil.FormatJSON, slog.LevelInfo, logutil.ContextAttrs)
+	r.NoError(err)
+	var outbound http.Header
+	handler := Chain(httpp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		slog.New(h).InfoContext(r.Context(), "handler detail")
+		outbound = make(http.Header)
+		tracectx.InjectHTTP(r.Context(), outbound)
+		return nil
+	}), NewLogMiddleware(slog.New(h)))
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set(tracectx.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	a.NoError(handler.ServeErrHTTP(httptest.NewRecorder(), req))
+
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	r.Len(lines, 2)
+	for _, line := range lines {
+		a.Contains(line, `"trace_id":"4bf92f3577b34da6a3ce929d0e0e4736"`)
+		a.Equal(1, strings.Count(line, `"trace_id"`))
+	}
+	a.Contains(lines[0], `"request_id"`)
+	a.Regexp(`^00-4bf92f3577b34da6a3ce929d0e0e4736-[0-9a-f]{16}-01$`, outbound.Get(tracectx.TraceparentHeader))
+	a.NotContains(outbound.Get(tracectx.TraceparentHeader), "00f067aa0ba902b7")
+}
@@ -2,9 +2,7 @@ package httpmw
 
 import "github.com/authenticvision/util-go/httpp"
 
-type Middleware interface {
-	Middleware(handler httpp.Handler) httpp.Handler
-}
+type Middleware = httpp.Middleware
 
 // Chain adds each Middleware to handler. The last middleware is the outermost.
 func Chain(handler httpp.Handler, middlewares ...Middleware) httpp.Handler {
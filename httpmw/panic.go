@@ -25,6 +25,8 @@ func (e PanicError) Unwrap() error {
 }
 
 // NewPanicMiddleware logs handler panics and returns them as error via PanicError.
+// Like any other handler error, the panic is written to the client by NewLogMiddleware's
+// httpp.ErrorRenderer.
 func NewPanicMiddleware() Middleware {
 	return &panicMiddleware{}
 }
@@ -0,0 +1,24 @@
+package httpmw
+
+import (
+	"net/http"
+
+	"github.com/authenticvision/util-go/httpp"
+)
+
+// NewErrorRendererMiddleware installs renderer for writing errors to clients. Errors are rendered
+// by NewLogMiddleware, so this middleware must be added after it, i.e. as outer middleware.
+func NewErrorRendererMiddleware(renderer httpp.ErrorRenderer) Middleware {
+	return &errorRendererMiddleware{renderer: renderer}
+}
+
+type errorRendererMiddleware struct {
+	renderer httpp.ErrorRenderer
+}
+
+func (m *errorRendererMiddleware) Middleware(next httpp.Handler) httpp.Handler {
+	return httpp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		r = r.WithContext(httpp.WithErrorRenderer(r.Context(), m.renderer))
+		return next.ServeErrHTTP(w, r)
+	})
+}
@@ -0,0 +1,40 @@
+package httpmw
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/authenticvision/util-go/httpp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorRenderer_CrossOriginProtection(t *testing.T) {
+	a := assert.New(t)
+
+	var rendered error
+	renderer := httpp.ErrorRendererFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+		rendered = err
+		w.WriteHeader(httpp.PublicProblem(err).Status)
+		_, _ = io.WriteString(w, "request "+w.Header().Get(httpp.RequestIDHeader))
+	})
+	handler := Chain(httpp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		t.Error("handler must not be reached")
+		return nil
+	}),
+		NewCrossOriginProtection(),
+		NewLogMiddleware(slog.New(slog.DiscardHandler)),
+		NewErrorRendererMiddleware(renderer),
+	)
+
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.Header.Set("Sec-Fetch-Site", "cross-site")
+	w := httptest.NewRecorder()
+	a.NoError(handler.ServeErrHTTP(w, req))
+
+	a.Error(rendered)
+	a.Equal(http.StatusForbidden, w.Code)
+	a.Equal("request "+w.Header().Get(httpp.RequestIDHeader), w.Body.String())
+}
@@ -3,8 +3,10 @@ package httpp
 import (
 	"encoding/json"
 	"log/slog"
+	"math"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/authenticvision/util-go/logutil"
 )
@@ -21,15 +23,18 @@ func JSONStatus(w http.ResponseWriter, resp any, statusCode int) error {
 		return ServerError(err, "encoding failure")
 	}
 
-	hdr := w.Header()
-	hdr.Set("Content-Type", "application/json")
+	w.Header().Set("Content-Type", "application/json")
+	return writeBody(w, buf, statusCode)
+}
+
+func writeBody(w http.ResponseWriter, buf []byte, statusCode int) error {
 	if !PrefersVariableContentLength(w) {
-		hdr.Set("Content-Length", strconv.Itoa(len(buf)))
+		w.Header().Set("Content-Length", strconv.Itoa(len(buf)))
 	}
 
 	w.WriteHeader(statusCode)
 
-	_, err = w.Write(buf)
+	_, err := w.Write(buf)
 	if err != nil {
 		// This usually fails with an I/O error when the client disconnects unexpectedly.
 		return logutil.Severity(ServerError(err, "JSON write failure"), slog.LevelWarn)
@@ -42,26 +47,48 @@ func JSON(w http.ResponseWriter, resp any) error {
 	return JSONStatus(w, resp, http.StatusOK)
 }
 
-func BadRequest(err error, public PublicMessage) error {
-	return logutil.Severity(Err(err, http.StatusBadRequest, public), slog.LevelWarn)
+func BadRequest(err error, public PublicMessage, opts ...ErrOption) error {
+	return logutil.Severity(Err(err, http.StatusBadRequest, public, opts...), slog.LevelWarn)
 }
 
-func Unauthorized(public PublicMessage) error {
-	return logutil.Severity(Err(nil, http.StatusUnauthorized, public), slog.LevelWarn)
+func Unauthorized(public PublicMessage, opts ...ErrOption) error {
+	return logutil.Severity(Err(nil, http.StatusUnauthorized, public, opts...), slog.LevelWarn)
 }
 
-func Forbidden(public PublicMessage) error {
-	return logutil.Severity(Err(nil, http.StatusForbidden, public), slog.LevelWarn)
+func Forbidden(public PublicMessage, opts ...ErrOption) error {
+	return logutil.Severity(Err(nil, http.StatusForbidden, public, opts...), slog.LevelWarn)
 }
 
-func NotFound(public PublicMessage) error {
-	return logutil.Severity(Err(nil, http.StatusNotFound, public), slog.LevelWarn)
+func NotFound(public PublicMessage, opts ...ErrOption) error {
+	return logutil.Severity(Err(nil, http.StatusNotFound, public, opts...), slog.LevelWarn)
 }
 
-func Unprocessable(err error, public PublicMessage) error {
-	return logutil.Severity(Err(err, http.StatusUnprocessableEntity, public), slog.LevelWarn)
+func Unprocessable(err error, public PublicMessage, opts ...ErrOption) error {
+	return logutil.Severity(Err(err, http.StatusUnprocessableEntity, public, opts...), slog.LevelWarn)
+}
+
+// TooManyRequests is returned when a client exceeds a rate limit. A positive retryAfter is sent
+// as Retry-After header.
+func TooManyRequests(retryAfter time.Duration, public PublicMessage, opts ...ErrOption) error {
+	opts = append(retryAfterOption(retryAfter), opts...)
+	return logutil.Severity(Err(nil, http.StatusTooManyRequests, public, opts...), slog.LevelWarn)
+}
+
+// Unavailable is returned when the server is temporarily overloaded or in maintenance. A positive
+// retryAfter is sent as Retry-After header.
+func Unavailable(retryAfter time.Duration, public PublicMessage, opts ...ErrOption) error {
+	opts = append(retryAfterOption(retryAfter), opts...)
+	return logutil.Severity(Err(nil, http.StatusServiceUnavailable, public, opts...), slog.LevelWarn)
+}
+
+func retryAfterOption(retryAfter time.Duration) []ErrOption {
+	if retryAfter <= 0 {
+		return nil
+	}
+	seconds := int64(math.Ceil(retryAfter.Seconds()))
+	return []ErrOption{WithHeader("Retry-After", strconv.FormatInt(seconds, 10))}
 }
 
-func ServerError(err error, public PublicMessage) error {
-	return Err(err, http.StatusInternalServerError, public)
+func ServerError(err error, public PublicMessage, opts ...ErrOption) error {
+	return Err(err, http.StatusInternalServerError, public, opts...)
 }
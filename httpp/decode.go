@@ -0,0 +1,304 @@
+package httpp
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"mime"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/authenticvision/util-go/bsize"
+	"github.com/authenticvision/util-go/logutil"
+)
+
+// DefaultMaxJSONBytes is the request body limit of DecodeJSON if none is configured.
+const DefaultMaxJSONBytes = 1 * bsize.MiB
+
+// DecodeOptions configures DecodeJSON. The zero value is valid.
+type DecodeOptions struct {
+	// MaxBytes limits the request body size, defaults to DefaultMaxJSONBytes.
+	MaxBytes bsize.Bytes
+
+	// DisallowUnknownFields rejects objects with keys that do not map to a struct field.
+	DisallowUnknownFields bool
+
+	// AllowMissingContentType accepts requests without Content-Type header, e.g. for curl users.
+	// A Content-Type other than JSON is always rejected.
+	AllowMissingContentType bool
+}
+
+// Validator is implemented by request types which check their own consistency after decoding.
+// Validate may return an error created through Err to control the response, e.g. via FieldError.
+// Any other error is reported as 422 Unprocessable Entity with a generic message.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError reports an invalid field during validation. The path is forwarded to the client as
+// part of the public message and as "field" problem extension.
+func FieldError(path string, public PublicMessage) error {
+	return Unprocessable(nil, PublicMessage(path)+": "+public, WithExtension("field", path))
+}
+
+// DecodeJSON reads a single JSON value of type T from the request body. If T or *T implements
+// Validator, the decoded value is validated. All failures are returned as errors created through
+// Err with a public message that is safe to forward to the client.
+func DecodeJSON[T any](r *http.Request, opts DecodeOptions) (T, error) {
+	var v T
+	if err := decodeJSON(r, opts, &v); err != nil {
+		return v, err
+	}
+	if err := validate(&v); err != nil {
+		return v, err
+	}
+	return v, nil
+}
+
+func decodeJSON(r *http.Request, opts DecodeOptions, v any) error {
+	if err := checkJSONContentType(r, opts.AllowMissingContentType); err != nil {
+		return err
+	}
+
+	maxBytes := opts.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = DefaultMaxJSONBytes
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(nil, r.Body, int64(maxBytes)))
+	if err != nil {
+		return decodeError(err, maxBytes)
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if opts.DisallowUnknownFields {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		if opts.DisallowUnknownFields {
+			if path, ok := unknownField(data, reflect.TypeOf(v).Elem(), ""); ok {
+				msg := PublicMessage(fmt.Sprintf("unknown field %q", path))
+				return BadRequest(err, msg, WithExtension("field", path))
+			}
+		}
+		return decodeError(err, maxBytes)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		if err != nil {
+			return decodeError(err, maxBytes)
+		}
+		return BadRequest(nil, "unexpected data after JSON value")
+	}
+	return nil
+}
+
+// validate calls Validate if ptr or the value it points to implements Validator. A nil value, e.g.
+// a pointer decoded from JSON null, is not validated.
+func validate(ptr any) error {
+	validator, ok := ptr.(Validator)
+	if !ok {
+		elem := reflect.ValueOf(ptr).Elem()
+		switch elem.Kind() {
+		case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice:
+			if elem.IsNil() {
+				return nil
+			}
+		}
+		validator, ok = elem.Interface().(Validator)
+	}
+	if !ok {
+		return nil
+	}
+	if err := validator.Validate(); err != nil {
+		var httpErr httpError
+		if errors.As(err, &httpErr) {
+			return err
+		}
+		return Unprocessable(err, "request validation failed")
+	}
+	return nil
+}
+
+func checkJSONContentType(r *http.Request, allowMissing bool) error {
+	contentType := r.Header.Get("Content-Type")
+	if contentType == "" {
+		if allowMissing {
+			return nil
+		}
+		return unsupportedMediaType(nil, "expected Content-Type application/json")
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return unsupportedMediaType(err, "malformed Content-Type")
+	}
+	if mediaType != "application/json" && !(strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json")) {
+		return unsupportedMediaType(nil, "expected Content-Type application/json")
+	}
+	return nil
+}
+
+func unsupportedMediaType(err error, public PublicMessage) error {
+	return logutil.Severity(Err(err, http.StatusUnsupportedMediaType, public), slog.LevelWarn)
+}
+
+// decodeError maps encoding/json errors to public errors. Paths in messages are either derived
+// from the target type or echo the client's own input, and are therefore safe to forward.
+func decodeError(err error, maxBytes bsize.Bytes) error {
+	var (
+		maxBytesErr *http.MaxBytesError
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &maxBytesErr):
+		msg := PublicMessage(fmt.Sprintf("request body exceeds limit of %s", maxBytes))
+		return tooLarge(err, msg)
+	case errors.Is(err, io.EOF):
+		return BadRequest(err, "request body is empty")
+	case errors.Is(err, io.ErrUnexpectedEOF):
+		return BadRequest(err, "request body contains truncated JSON")
+	case errors.As(err, &syntaxErr):
+		msg := PublicMessage(fmt.Sprintf("malformed JSON at offset %d", syntaxErr.Offset))
+		return BadRequest(err, msg)
+	case errors.As(err, &typeErr):
+		path := typeErr.Field
+		if path == "" {
+			path = "$"
+		}
+		msg := PublicMessage(fmt.Sprintf("invalid value for %q: expected %s, got %s",
+			path, jsonKind(typeErr.Type), typeErr.Value))
+		return BadRequest(err, msg, WithExtension("field", path))
+	}
+	return BadRequest(err, "invalid JSON value")
+}
+
+var jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+
+// unknownField returns the path of an object key in data that does not map to a field of t, in
+// the same notation as json.UnmarshalTypeError.Field. Keys are matched case-insensitively, like
+// encoding/json does. Values of types with custom decoding are not inspected.
+func unknownField(data []byte, t reflect.Type, path string) (string, bool) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(jsonUnmarshalerType) {
+		return "", false
+	}
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(data, &obj) != nil {
+			return "", false
+		}
+		fields := jsonFields(t)
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			field, ok := fields[strings.ToLower(key)]
+			if !ok {
+				return join(key), true
+			}
+			if path, ok := unknownField(obj[key], field, join(key)); ok {
+				return path, true
+			}
+		}
+	case reflect.Map:
+		var obj map[string]json.RawMessage
+		if json.Unmarshal(data, &obj) != nil {
+			return "", false
+		}
+		for _, key := range slices.Sorted(maps.Keys(obj)) {
+			if path, ok := unknownField(obj[key], t.Elem(), join(key)); ok {
+				return path, true
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		var arr []json.RawMessage
+		if json.Unmarshal(data, &arr) != nil {
+			return "", false
+		}
+		for i, elem := range arr {
+			if path, ok := unknownField(elem, t.Elem(), join(strconv.Itoa(i))); ok {
+				return path, true
+			}
+		}
+	}
+	return "", false
+}
+
+// jsonFields maps the lowercase JSON names of a struct's fields to their types, including fields
+// promoted from embedded structs.
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || len(f.Index) > 1 && !promotedJSONField(t, f.Index) {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" && f.Tag.Get("json") == "-" {
+			continue
+		}
+		ft := f.Type
+		for ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+			continue // its fields are promoted
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[strings.ToLower(name)] = f.Type
+	}
+	return fields
+}
+
+// promotedJSONField reports whether the field at index is promoted by encoding/json, i.e. all
+// structs along its path are embedded without JSON name.
+func promotedJSONField(t reflect.Type, index []int) bool {
+	for _, i := range index[:len(index)-1] {
+		f := t.Field(i)
+		if name, _, _ := strings.Cut(f.Tag.Get("json"), ","); name != "" {
+			return false
+		}
+		t = f.Type
+		for t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	return true
+}
+
+// jsonKind describes a Go type in terms of JSON, so that Go type names are not exposed.
+func jsonKind(t reflect.Type) string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "non-negative integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	case reflect.Map, reflect.Struct:
+		return "object"
+	default:
+		return "value"
+	}
+}
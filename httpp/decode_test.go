@@ -0,0 +1,140 @@
+package httpp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type decodeTestItem struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+type decodeTestRequest struct {
+	Items []decodeTestItem `json:"items"`
+}
+
+func (d decodeTestRequest) Validate() error {
+	if len(d.Items) == 0 {
+		return FieldError("items", "must not be empty")
+	}
+	return nil
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		opts        DecodeOptions
+		wantStatus  int
+		wantMessage string
+	}{
+		{name: "valid", body: `{"items":[{"name":"a","count":1}]}`},
+		{name: "missing content type", contentType: "-", body: `{}`,
+			wantStatus: http.StatusUnsupportedMediaType, wantMessage: "expected Content-Type application/json"},
+		{name: "wrong content type", contentType: "text/plain", body: `{}`,
+			wantStatus: http.StatusUnsupportedMediaType, wantMessage: "expected Content-Type application/json"},
+		{name: "empty", body: ``,
+			wantStatus: http.StatusBadRequest, wantMessage: "request body is empty"},
+		{name: "syntax", body: `{"items":]`,
+			wantStatus: http.StatusBadRequest, wantMessage: "malformed JSON at offset 10"},
+		{name: "trailing data", body: `{"items":[{}]} {}`,
+			wantStatus: http.StatusBadRequest, wantMessage: "unexpected data after JSON value"},
+		{name: "type", body: `{"items":[{"count":"one"}]}`,
+			wantStatus: http.StatusBadRequest, wantMessage: `invalid value for "items.0.count": expected integer, got string`},
+		{name: "unknown field", body: `{"items":[{"nam":"a"}]}`, opts: DecodeOptions{DisallowUnknownFields: true},
+			wantStatus: http.StatusBadRequest, wantMessage: `unknown field "items.0.nam"`},
+		{name: "known field in other case", body: `{"Items":[{"NAME":"a","count":1}]}`, opts: DecodeOptions{DisallowUnknownFields: true}},
+		{name: "too large", body: `{"items":[{"name":"aaaaaaaaaaaaaaaa"}]}`, opts: DecodeOptions{MaxBytes: 16},
+			wantStatus: http.StatusRequestEntityTooLarge, wantMessage: "request body exceeds limit of 16B"},
+		{name: "validation", body: `{"items":[]}`,
+			wantStatus: http.StatusUnprocessableEntity, wantMessage: "items: must not be empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			switch tt.contentType {
+			case "":
+				r.Header.Set("Content-Type", "application/json; charset=utf-8")
+			case "-":
+			default:
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			v, err := DecodeJSON[decodeTestRequest](r, tt.opts)
+			if tt.wantStatus == 0 {
+				a.NoError(err)
+				a.Equal(decodeTestRequest{Items: []decodeTestItem{{Name: "a", Count: 1}}}, v)
+				return
+			}
+			var httpErr httpError
+			if a.True(errors.As(err, &httpErr), "error %v", err) {
+				a.Equal(tt.wantStatus, httpErr.StatusCode())
+				a.Equal(tt.wantMessage, httpErr.StatusText())
+			}
+		})
+	}
+}
+
+type decodeTestPointerRequest struct {
+	Name string `json:"name"`
+}
+
+func (d *decodeTestPointerRequest) Validate() error {
+	if d.Name == "" {
+		return FieldError("name", "must not be empty")
+	}
+	return nil
+}
+
+func TestDecodeJSON_Null(t *testing.T) {
+	a := assert.New(t)
+
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`null`))
+	r.Header.Set("Content-Type", "application/json")
+	v, err := DecodeJSON[*decodeTestPointerRequest](r, DecodeOptions{})
+	a.NoError(err)
+	a.Nil(v)
+}
+
+func TestUnknownField(t *testing.T) {
+	type Base struct {
+		ID string `json:"id"`
+	}
+	type Named struct {
+		Label string
+	}
+	type request struct {
+		Base
+		Named   `json:"named"`
+		Ignored string            `json:"-"`
+		Tags    map[string]Base   `json:"tags"`
+		Raw     json.RawMessage   `json:"raw"`
+		Items   []*decodeTestItem `json:"items,omitempty"`
+	}
+	tests := []struct {
+		body     string
+		wantPath string
+	}{
+		{body: `{"id":"a","named":{"Label":"x"},"tags":{"t":{"id":"b"}},"raw":{"any":1},"items":[null,{"name":"n"}]}`},
+		{body: `{"Label":"x"}`, wantPath: "Label"},
+		{body: `{"Ignored":"x"}`, wantPath: "Ignored"},
+		{body: `{"tags":{"t":{"name":"b"}}}`, wantPath: "tags.t.name"},
+		{body: `{"items":[{"name":"n"},{"nam":"n"}]}`, wantPath: "items.1.nam"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			path, ok := unknownField([]byte(tt.body), reflect.TypeFor[request](), "")
+			assert.Equal(t, tt.wantPath != "", ok)
+			assert.Equal(t, tt.wantPath, path)
+		})
+	}
+}
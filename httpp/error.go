@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strings"
 )
 
@@ -13,18 +14,64 @@ type PublicMessage string
 
 const DefaultMessage PublicMessage = ""
 
-func Err(err error, statusCode int, msg PublicMessage) error {
-	return httpError{
+// ErrOption attaches additional public information to an error created through Err.
+type ErrOption func(*httpError)
+
+// WithProblemType sets the RFC 9457 problem type URI that is sent to clients which accept
+// problem details. It defaults to "about:blank", i.e. the HTTP status code is the problem type.
+func WithProblemType(uri ProblemType) ErrOption {
+	return func(e *httpError) {
+		e.problemType = uri
+	}
+}
+
+// WithExtension adds an RFC 9457 extension member to the problem details sent to clients.
+// Like PublicMessage, value is sent to the client as-is and must not contain confidential data.
+// The value must be encodable as JSON. Standard members, e.g. "status", cannot be overridden.
+func WithExtension(key string, value any) ErrOption {
+	if isProblemMember(key) {
+		panic(fmt.Sprintf("httpp.WithExtension: %q is a standard problem member", key))
+	}
+	return func(e *httpError) {
+		e.extensions = append(e.extensions, problemExtension{key: key, value: value})
+	}
+}
+
+// WithHeader adds a response header that is sent along with the error, e.g. Retry-After or Allow.
+// Like PublicMessage, the value is sent to the client as-is and must not contain confidential data.
+func WithHeader(key, value string) ErrOption {
+	return func(e *httpError) {
+		if e.header == nil {
+			e.header = make(http.Header)
+		}
+		e.header.Add(key, value)
+	}
+}
+
+func Err(err error, statusCode int, msg PublicMessage, opts ...ErrOption) error {
+	e := httpError{
 		err:        err,
 		statusCode: statusCode,
 		msg:        msg,
 	}
+	for _, opt := range opts {
+		opt(&e)
+	}
+	return e
 }
 
 type httpError struct {
-	err        error
-	statusCode int
-	msg        PublicMessage
+	err         error
+	statusCode  int
+	msg         PublicMessage
+	problemType ProblemType
+	extensions  []problemExtension
+	header      http.Header
+}
+
+type problemExtension struct {
+	key   string
+	value any
 }
 
 func (e httpError) Error() string {
@@ -54,16 +101,48 @@ func (e httpError) StatusText() string {
 	return http.StatusText(e.statusCode)
 }
 
-// WriteError writes err as HTTP response. Middlewares that act on errors use WriteError to forward
-// errors to clients. It is invalid to write an error when an HTTP response was previously started.
+// WriteError writes err as plain text HTTP response. It is invalid to write an error when an HTTP
+// response was previously started.
+//
+// Deprecated: Use WriteErrorFor, which negotiates problem details with the client. Middlewares
+// that act on errors should use RenderError to honor the request's ErrorRenderer.
 func WriteError(w http.ResponseWriter, err error) {
 	if err == nil {
 		panic("httpp.WriteError called with nil error")
 	}
+	writePlainError(w, err)
+}
+
+// WriteErrorFor writes err as HTTP response. Clients which accept application/problem+json receive
+// an RFC 9457 problem details object, others receive plain text. It is invalid to write an error
+// when an HTTP response was previously started.
+func WriteErrorFor(w http.ResponseWriter, r *http.Request, err error) {
+	if err == nil {
+		panic("httpp.WriteErrorFor called with nil error")
+	}
+	if acceptsProblem(r) {
+		problem := PublicProblem(err)
+		problem.Instance = w.Header().Get(RequestIDHeader)
+		writeProblem(w, problem)
+		return
+	}
+	writePlainError(w, err)
+}
+
+func writePlainError(w http.ResponseWriter, err error) {
 	var httpErr httpError
 	if errors.As(err, &httpErr) {
+		setHeaders(w, httpErr.header)
 		http.Error(w, httpErr.StatusText(), httpErr.StatusCode())
-	} else if err != nil {
+	} else {
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 	}
 }
+
+// setHeaders copies public headers of an error to the response.
+func setHeaders(w http.ResponseWriter, header http.Header) {
+	hdr := w.Header()
+	for key, values := range header {
+		hdr[key] = slices.Clone(values)
+	}
+}
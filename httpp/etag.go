@@ -0,0 +1,139 @@
+package httpp
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/authenticvision/util-go/logutil"
+)
+
+// ETagFunc computes an entity tag, including quotes and weakness indicator, over a response body.
+type ETagFunc func(body []byte) string
+
+// StrongETag derives a strong entity tag from a hash of body.
+func StrongETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return etagFromSum(sum[:])
+}
+
+func etagFromSum(sum []byte) string {
+	return `"` + base64.RawURLEncoding.EncodeToString(sum[:18]) + `"`
+}
+
+// WeakETag derives a weak entity tag from a hash of body. Weak tags permit servers and proxies to
+// transform the body, but prevent its use for range requests.
+func WeakETag(body []byte) string {
+	return "W/" + StrongETag(body)
+}
+
+// JSONETag is JSON with support for conditional requests. The ETag is computed via etag, unless
+// the handler already set an ETag header. A Last-Modified header set by the handler is honored
+// as well. See CheckPreconditions for the evaluated request headers.
+func JSONETag(w http.ResponseWriter, r *http.Request, resp any, etag ETagFunc) error {
+	buf, err := json.Marshal(resp)
+	if err != nil {
+		// A custom serializer likely returned an error.
+		return ServerError(err, "encoding failure")
+	}
+
+	hdr := w.Header()
+	if hdr.Get("ETag") == "" {
+		hdr.Set("ETag", etag(buf))
+	}
+	if done, err := CheckPreconditions(w, r); done || err != nil {
+		return err
+	}
+
+	hdr.Set("Content-Type", "application/json")
+	return writeBody(w, buf, http.StatusOK)
+}
+
+// CheckPreconditions evaluates conditional request headers as per RFC 9110 section 13.2.2 against
+// the ETag and Last-Modified response headers, which must be set before calling it:
+//
+//   - If-Match and If-Unmodified-Since fail with a 412 Precondition Failed error. Handlers of
+//     state-changing requests should call CheckPreconditions with the resource's current ETag
+//     before applying changes, to implement optimistic concurrency control.
+//   - If-None-Match and If-Modified-Since cause a 304 Not Modified response for GET and HEAD
+//     requests, which is written directly. If-None-Match fails with 412 for other methods.
+//
+// A current representation of the resource is assumed to exist, i.e. "*" always matches.
+// It returns done=true when the response was written and the handler should return.
+func CheckPreconditions(w http.ResponseWriter, r *http.Request) (done bool, err error) {
+	hdr := w.Header()
+	etag := hdr.Get("ETag")
+	lastModified, _ := http.ParseTime(hdr.Get("Last-Modified"))
+	isRead := r.Method == http.MethodGet || r.Method == http.MethodHead
+
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" {
+		if !matchETag(ifMatch, etag, false) {
+			return false, preconditionFailed("If-Match")
+		}
+	} else if ius, err := http.ParseTime(r.Header.Get("If-Unmodified-Since")); err == nil && !lastModified.IsZero() {
+		if lastModified.Truncate(time.Second).After(ius) {
+			return false, preconditionFailed("If-Unmodified-Since")
+		}
+	}
+
+	if ifNoneMatch := r.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		if matchETag(ifNoneMatch, etag, true) {
+			if isRead {
+				writeNotModified(w)
+				return true, nil
+			}
+			return false, preconditionFailed("If-None-Match")
+		}
+	} else if ims, err := http.ParseTime(r.Header.Get("If-Modified-Since")); err == nil && isRead && !lastModified.IsZero() {
+		if !lastModified.Truncate(time.Second).After(ims) {
+			writeNotModified(w)
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func preconditionFailed(header string) error {
+	// header is a constant, so the message is safe to forward
+	msg := PublicMessage(header + " precondition failed")
+	return logutil.Severity(Err(nil, http.StatusPreconditionFailed, msg), slog.LevelInfo)
+}
+
+// writeNotModified writes a 304 response, keeping only headers permitted by RFC 9110 15.4.5.
+func writeNotModified(w http.ResponseWriter) {
+	hdr := w.Header()
+	hdr.Del("Content-Type")
+	hdr.Del("Content-Length")
+	hdr.Del("Content-Encoding")
+	w.WriteHeader(http.StatusNotModified)
+}
+
+// matchETag checks whether etag is listed in the header value of If-Match or If-None-Match.
+// Weak comparison ignores the weakness indicator, strong comparison requires both to be strong.
+// "*" matches any current representation, even one without an ETag.
+func matchETag(header, etag string, weak bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if etag == "" {
+		return false
+	}
+	etagWeak := strings.HasPrefix(etag, "W/")
+	etagOpaque := strings.TrimPrefix(etag, "W/")
+	for candidate := range strings.SplitSeq(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		candidateWeak := strings.HasPrefix(candidate, "W/")
+		if !weak && (etagWeak || candidateWeak) {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == etagOpaque {
+			return true
+		}
+	}
+	return false
+}
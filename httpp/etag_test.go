@@ -0,0 +1,44 @@
+package httpp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckPreconditions_IfMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		ifMatch string
+		etag    string
+		wantErr bool
+	}{
+		{name: "match", ifMatch: `"v1"`, etag: `"v1"`},
+		{name: "mismatch", ifMatch: `"v1"`, etag: `"v2"`, wantErr: true},
+		{name: "weak", ifMatch: `W/"v1"`, etag: `W/"v1"`, wantErr: true},
+		{name: "list", ifMatch: `"v0", "v1"`, etag: `"v1"`},
+		{name: "any", ifMatch: "*", etag: `"v1"`},
+		{name: "any without etag", ifMatch: "*"},
+		{name: "without etag", ifMatch: `"v1"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			req := httptest.NewRequest(http.MethodPut, "/", nil)
+			req.Header.Set("If-Match", tt.ifMatch)
+			w := httptest.NewRecorder()
+			if tt.etag != "" {
+				w.Header().Set("ETag", tt.etag)
+			}
+			done, err := CheckPreconditions(w, req)
+			a.False(done)
+			if tt.wantErr {
+				a.Equal(http.StatusPreconditionFailed, PublicProblem(err).Status)
+			} else {
+				a.NoError(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package httpp
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/authenticvision/util-go/logutil"
+)
+
+// FileServerOptions configures FileServer. The zero value serves files as-is.
+type FileServerOptions struct {
+	// Index is served for directories, defaults to "index.html".
+	Index string
+
+	// SPAFallback serves the root Index for paths that do not exist and have no file extension,
+	// so that client-side routing of single-page applications works after a reload.
+	SPAFallback bool
+
+	// Precompressed serves "<name>.zst" and "<name>.gz" instead of "<name>" if such a sibling
+	// exists and the client accepts the encoding.
+	Precompressed bool
+
+	// Immutable reports whether a file's name contains a content hash, so that clients can cache
+	// it forever. Defaults to HashedAssetName. All other files must be revalidated by clients.
+	Immutable func(name string) bool
+}
+
+// FileServer serves files from fsys, e.g. an embed.FS, with support for Range and conditional
+// requests via http.ServeContent. Strong ETags are derived from file contents and cached.
+// Missing files are returned as NotFound error. Directory listings are not supported.
+// Responses are never compressed by httpmw.NewCompressionMiddleware, use Precompressed instead.
+func FileServer(fsys fs.FS, opts FileServerOptions) Handler {
+	if opts.Index == "" {
+		opts.Index = "index.html"
+	}
+	if opts.Immutable == nil {
+		opts.Immutable = HashedAssetName
+	}
+	return &fileServer{fsys: fsys, opts: opts}
+}
+
+var fileEncodings = []struct {
+	name, suffix string
+}{
+	// earlier encodings are preferred, like in httpmw.NewCompressionMiddleware
+	{name: "zstd", suffix: ".zst"},
+	{name: "gzip", suffix: ".gz"},
+}
+
+type fileServer struct {
+	fsys  fs.FS
+	opts  FileServerOptions
+	etags sync.Map // fileETagKey -> string
+}
+
+type fileETagKey struct {
+	name    string
+	size    int64
+	modTime time.Time
+}
+
+func (s *fileServer) ServeErrHTTP(w http.ResponseWriter, r *http.Request) error {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		const allow = "GET, HEAD, OPTIONS"
+		if r.Method == http.MethodOptions {
+			w.Header().Set("Allow", allow)
+			return NoContent(w)
+		}
+		return logutil.Severity(Err(nil, http.StatusMethodNotAllowed, DefaultMessage, WithHeader("Allow", allow)), slog.LevelWarn)
+	}
+
+	name := path.Clean("/" + r.URL.Path)[1:]
+	if name == "" {
+		name = "."
+	} else if !fs.ValidPath(name) {
+		return NotFound(DefaultMessage)
+	}
+	info, err := fs.Stat(s.fsys, name)
+	if err == nil && info.IsDir() {
+		name = path.Join(name, s.opts.Index)
+		info, err = fs.Stat(s.fsys, name)
+	}
+	if errors.Is(err, fs.ErrNotExist) && s.opts.SPAFallback && path.Ext(name) == "" {
+		name = s.opts.Index
+		info, err = fs.Stat(s.fsys, name)
+	}
+	if errors.Is(err, fs.ErrNotExist) || (err == nil && info.IsDir()) {
+		return NotFound(DefaultMessage)
+	} else if err != nil {
+		return ServerError(err, DefaultMessage)
+	}
+
+	hdr := w.Header()
+	if ctype := mime.TypeByExtension(path.Ext(name)); ctype != "" {
+		hdr.Set("Content-Type", ctype)
+	}
+	if s.opts.Immutable(name) {
+		hdr.Set("Cache-Control", "public, max-age=31536000, immutable")
+	} else {
+		hdr.Set("Cache-Control", "no-cache")
+	}
+	DisableCompression(w)
+
+	if s.opts.Precompressed {
+		accepted := r.Header.Get("Accept-Encoding")
+		for _, enc := range fileEncodings {
+			if !acceptsEncoding(accepted, enc.name) {
+				continue
+			}
+			encInfo, err := fs.Stat(s.fsys, name+enc.suffix)
+			if err != nil || encInfo.IsDir() {
+				continue
+			}
+			if hdr.Get("Content-Type") == "" {
+				hdr.Set("Content-Type", "application/octet-stream") // don't sniff compressed data
+			}
+			hdr.Set("Content-Encoding", enc.name)
+			addVary(hdr, "Accept-Encoding")
+			return s.serveFile(w, r, name+enc.suffix, encInfo)
+		}
+		addVary(hdr, "Accept-Encoding")
+	}
+
+	return s.serveFile(w, r, name, info)
+}
+
+func (s *fileServer) serveFile(w http.ResponseWriter, r *http.Request, name string, info fs.FileInfo) error {
+	f, err := s.fsys.Open(name)
+	if err != nil {
+		return ServerError(err, DefaultMessage)
+	}
+	defer func() { _ = f.Close() }()
+
+	content, ok := f.(io.ReadSeeker)
+	if !ok {
+		buf, err := io.ReadAll(f)
+		if err != nil {
+			return ServerError(fmt.Errorf("read %q: %w", name, err), DefaultMessage)
+		}
+		content = bytes.NewReader(buf)
+	}
+
+	etag, err := s.etag(name, info, content)
+	if err != nil {
+		return ServerError(err, DefaultMessage)
+	}
+	w.Header().Set("ETag", etag)
+
+	// ServeContent writes 206, 304, 412 and 416 responses on its own, none of which are errors.
+	http.ServeContent(w, r, name, info.ModTime(), content)
+	return nil
+}
+
+// etag returns the cached ETag for a file, or computes it from content and rewinds content.
+func (s *fileServer) etag(name string, info fs.FileInfo, content io.ReadSeeker) (string, error) {
+	key := fileETagKey{name: name, size: info.Size(), modTime: info.ModTime()}
+	if etag, ok := s.etags.Load(key); ok {
+		return etag.(string), nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, content); err != nil {
+		return "", fmt.Errorf("hash %q: %w", name, err)
+	}
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return "", fmt.Errorf("rewind %q: %w", name, err)
+	}
+	etag := etagFromSum(h.Sum(nil))
+	s.etags.Store(key, etag)
+	return etag, nil
+}
+
+func acceptsEncoding(header, name string) bool {
+	for candidate := range strings.SplitSeq(header, ",") {
+		if enc, q := parseMediaRange(candidate); enc == name {
+			return q > 0
+		}
+	}
+	return false
+}
+
+// addVary adds value to the Vary header, unless it is already listed.
+func addVary(hdr http.Header, value string) {
+	for _, vary := range hdr.Values("Vary") {
+		for field := range strings.SplitSeq(vary, ",") {
+			if field = strings.TrimSpace(field); field == "*" || strings.EqualFold(field, value) {
+				return
+			}
+		}
+	}
+	hdr.Add("Vary", value)
+}
+
+// HashedAssetName detects names of build artifacts with a content hash, such as "app.3f2a1b9c.js"
+// or "index-BX3s9aK2.css", as produced by common bundlers. The hash must immediately precede the
+// extension, separated by '.' or '-', and contain both digits and letters. It is either lowercase
+// hex of 8 to 64 characters, or 8 characters of base32 or base64url that include an uppercase
+// letter. Names such as "chapter-2024ab12.html" still match, so use FileServerOptions.Immutable
+// for directories with hand-written files.
+func HashedAssetName(name string) bool {
+	base := path.Base(name)
+	stem := strings.TrimSuffix(base, path.Ext(base))
+	for _, sep := range []string{".", "-"} {
+		if i := strings.LastIndex(stem, sep); i > 0 && isContentHash(stem[i+1:]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isContentHash(s string) bool {
+	if len(s) < 8 {
+		return false
+	}
+	var digit, lower, upper, other bool
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digit = true
+		case c >= 'a' && c <= 'f':
+			lower = true
+		case c >= 'g' && c <= 'z':
+			lower, other = true, true
+		case c >= 'A' && c <= 'Z':
+			upper = true
+		case c == '_':
+			other = true
+		default:
+			return false
+		}
+	}
+	if !upper && !other {
+		return len(s) <= 64 && digit && lower // hex
+	}
+	return len(s) == 8 && digit && upper // base32 or base64url
+}
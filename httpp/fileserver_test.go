@@ -0,0 +1,100 @@
+package httpp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileServer(t *testing.T) {
+	fsys := fstest.MapFS{
+		"index.html":                 {Data: []byte("<html>")},
+		"assets/app-BX3s9aK2.js":     {Data: []byte("console.log('hello world')")},
+		"assets/app-BX3s9aK2.js.zst": {Data: []byte("zstd data")},
+	}
+	h := FileServer(fsys, FileServerOptions{SPAFallback: true, Precompressed: true})
+
+	serve := func(path string, hdr map[string]string) (*httptest.ResponseRecorder, error) {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for k, v := range hdr {
+			req.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		return w, h.ServeErrHTTP(w, req)
+	}
+
+	t.Run("range", func(t *testing.T) {
+		a := assert.New(t)
+		w, err := serve("/assets/app-BX3s9aK2.js", map[string]string{"Range": "bytes=0-6"})
+		a.NoError(err)
+		a.Equal(http.StatusPartialContent, w.Code)
+		a.Equal("console", w.Body.String())
+		a.Equal("public, max-age=31536000, immutable", w.Header().Get("Cache-Control"))
+		a.Contains(w.Header().Get("Content-Type"), "javascript")
+	})
+
+	t.Run("precompressed", func(t *testing.T) {
+		a := assert.New(t)
+		w, err := serve("/assets/app-BX3s9aK2.js", map[string]string{"Accept-Encoding": "gzip, zstd"})
+		a.NoError(err)
+		a.Equal("zstd", w.Header().Get("Content-Encoding"))
+		a.Equal("zstd data", w.Body.String())
+		a.Equal([]string{"Accept-Encoding"}, w.Header().Values("Vary"))
+	})
+
+	t.Run("vary", func(t *testing.T) {
+		a := assert.New(t)
+		req := httptest.NewRequest(http.MethodGet, "/assets/app-BX3s9aK2.js", nil)
+		w := httptest.NewRecorder()
+		w.Header().Set("Vary", "Origin, accept-encoding") // e.g. set by a middleware
+		a.NoError(h.ServeErrHTTP(w, req))
+		a.Equal([]string{"Origin, accept-encoding"}, w.Header().Values("Vary"))
+	})
+
+	t.Run("spa fallback", func(t *testing.T) {
+		a := assert.New(t)
+		w, err := serve("/some/route", nil)
+		a.NoError(err)
+		a.Equal("<html>", w.Body.String())
+		a.Equal("no-cache", w.Header().Get("Cache-Control"))
+	})
+
+	t.Run("not modified", func(t *testing.T) {
+		a := assert.New(t)
+		w, err := serve("/", nil)
+		a.NoError(err)
+		w, err = serve("/", map[string]string{"If-None-Match": w.Header().Get("ETag")})
+		a.NoError(err)
+		a.Equal(http.StatusNotModified, w.Code)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := serve("/assets/missing.js", nil)
+		assert.Equal(t, http.StatusNotFound, PublicProblem(err).Status)
+	})
+}
+
+func TestHashedAssetName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "assets/index-BX3s9aK2.js", want: true},   // base64url
+		{name: "assets/index-MQ3NZLG5.js", want: true},   // base32
+		{name: "main.3f2a1b9c.js", want: true},           // hex
+		{name: "main.3f2a1b9c.chunk.js", want: false},    // hash must precede the extension
+		{name: "assets/index-component.js", want: false}, // no digits
+		{name: "index.html", want: false},
+		{name: "docs/chapter2.html", want: false},
+		{name: "docs/manual-chapter2.html", want: false},              // lowercase, but not hex
+		{name: "release-20240101.txt", want: false},                   // digits only
+		{name: "report-2024Q1v02.pdf", want: false},                   // mixed case, but not 8 characters
+		{name: "app.3f2a1b9c3f2a1b9c3f2a1b9c3f2a1b9c.js", want: true}, // hex of a longer hash
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, HashedAssetName(tt.name), tt.name)
+	}
+}
@@ -12,6 +12,11 @@ type Handler interface {
 	ServeErrHTTP(http.ResponseWriter, *http.Request) error
 }
 
+// Middleware wraps a Handler, see httpmw for implementations.
+type Middleware interface {
+	Middleware(handler Handler) Handler
+}
+
 type HandlerFunc func(http.ResponseWriter, *http.Request) error
 
 func (f HandlerFunc) ServeErrHTTP(w http.ResponseWriter, r *http.Request) error {
@@ -0,0 +1,271 @@
+package httpp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"os"
+	"sync"
+
+	"github.com/authenticvision/util-go/bsize"
+	"github.com/authenticvision/util-go/logutil"
+)
+
+// DefaultMultipartLimits is used by ParseMultipart for each zero field of MultipartLimits.
+var DefaultMultipartLimits = MultipartLimits{
+	MaxTotalBytes:   32 * bsize.MiB,
+	MaxPartBytes:    32 * bsize.MiB,
+	MaxParts:        100,
+	MaxValueBytes:   1 * bsize.MiB,
+	MemoryThreshold: 1 * bsize.MiB,
+}
+
+// MultipartLimits configures ParseMultipart.
+type MultipartLimits struct {
+	// MaxTotalBytes limits the size of the request body.
+	MaxTotalBytes bsize.Bytes
+
+	// MaxPartBytes limits the size of each part's content.
+	MaxPartBytes bsize.Bytes
+
+	// MaxParts limits the number of parts, including non-file form values.
+	MaxParts int
+
+	// MaxValueBytes limits the size of each non-file form value, in addition to MaxPartBytes.
+	// Values are always held in memory.
+	MaxValueBytes bsize.Bytes
+
+	// MemoryThreshold is the size above which a file part is written to a temporary file.
+	MemoryThreshold bsize.Bytes
+
+	// TempDir is the directory for temporary files, defaults to os.TempDir.
+	TempDir string
+}
+
+// Multipart is a parsed multipart/form-data request body.
+type Multipart struct {
+	Values map[string][]string
+	Files  map[string][]*MultipartFile
+
+	mu        sync.Mutex // guards temp files against concurrent cleanup
+	tempFiles []string
+	removed   bool
+}
+
+// MultipartFile is a file part of a multipart/form-data request body.
+type MultipartFile struct {
+	Filename string
+	Header   textproto.MIMEHeader
+	Size     int64
+
+	content []byte // if held in memory
+	path    string // if spooled to disk
+}
+
+// Open returns a reader for the file's content.
+func (f *MultipartFile) Open() (io.ReadSeekCloser, error) {
+	if f.path == "" {
+		return nopSeekCloser{bytes.NewReader(f.content)}, nil
+	}
+	file, err := os.Open(f.path)
+	if err != nil {
+		return nil, fmt.Errorf("open multipart temp file: %w", err)
+	}
+	return file, nil
+}
+
+type nopSeekCloser struct {
+	io.ReadSeeker
+}
+
+func (nopSeekCloser) Close() error {
+	return nil
+}
+
+// RemoveAll deletes all temporary files. It is called automatically when the request ends.
+func (m *Multipart) RemoveAll() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.removed = true
+	var errs []error
+	for _, path := range m.tempFiles {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	m.tempFiles = nil
+	return errors.Join(errs...)
+}
+
+func (m *Multipart) createTemp(dir string) (*os.File, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.removed {
+		return nil, errors.New("request ended")
+	}
+	f, err := os.CreateTemp(dir, "multipart-*")
+	if err != nil {
+		return nil, err
+	}
+	m.tempFiles = append(m.tempFiles, f.Name())
+	return f, nil
+}
+
+// ParseMultipart reads a multipart/form-data request body. File parts larger than
+// MemoryThreshold are streamed to temporary files, which are deleted when the request's context
+// is done, i.e. when the handler returns. Exceeded limits are returned as 413 error with a public
+// message that names the limit, malformed bodies as BadRequest.
+func ParseMultipart(r *http.Request, limits MultipartLimits) (*Multipart, error) {
+	limits = limits.withDefaults()
+
+	body := &maxBytesBody{ReadCloser: http.MaxBytesReader(nil, r.Body, int64(limits.MaxTotalBytes))}
+	r.Body = body
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, unsupportedMediaType(err, "expected Content-Type multipart/form-data")
+	}
+
+	m := &Multipart{
+		Values: make(map[string][]string),
+		Files:  make(map[string][]*MultipartFile),
+	}
+	context.AfterFunc(r.Context(), func() {
+		if err := m.RemoveAll(); err != nil {
+			logutil.FromContext(r.Context()).Warn("failed to remove multipart temp files", logutil.Err(err))
+		}
+	})
+
+	for count := 1; ; count++ {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			return m, nil
+		} else if err != nil {
+			return nil, multipartError(err, limits, body)
+		}
+		if count > limits.MaxParts {
+			_ = part.Close()
+			return nil, tooLarge(nil, PublicMessage(fmt.Sprintf("multipart body exceeds limit of %d parts", limits.MaxParts)))
+		}
+		err = m.readPart(part, limits, body)
+		_ = part.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (l MultipartLimits) withDefaults() MultipartLimits {
+	if l.MaxTotalBytes == 0 {
+		l.MaxTotalBytes = DefaultMultipartLimits.MaxTotalBytes
+	}
+	if l.MaxPartBytes == 0 {
+		l.MaxPartBytes = DefaultMultipartLimits.MaxPartBytes
+	}
+	if l.MaxParts == 0 {
+		l.MaxParts = DefaultMultipartLimits.MaxParts
+	}
+	if l.MaxValueBytes == 0 {
+		l.MaxValueBytes = DefaultMultipartLimits.MaxValueBytes
+	}
+	if l.MemoryThreshold == 0 {
+		l.MemoryThreshold = DefaultMultipartLimits.MemoryThreshold
+	}
+	return l
+}
+
+func (m *Multipart) readPart(part *multipart.Part, limits MultipartLimits, body *maxBytesBody) error {
+	name := part.FormName()
+	limited := io.LimitReader(part, int64(limits.MaxPartBytes)+1)
+	partTooLarge := func() error {
+		// the part's name is client input and thereby safe to echo
+		msg := PublicMessage(fmt.Sprintf("part %q exceeds size limit of %s", name, limits.MaxPartBytes))
+		return tooLarge(nil, msg)
+	}
+
+	var buf bytes.Buffer
+	if part.FileName() == "" {
+		// form values are always held in memory
+		n, err := io.CopyN(&buf, limited, int64(limits.MaxValueBytes)+1)
+		if err != nil && !errors.Is(err, io.EOF) {
+			return multipartError(err, limits, body)
+		}
+		if n > int64(limits.MaxPartBytes) {
+			return partTooLarge()
+		} else if n > int64(limits.MaxValueBytes) {
+			return tooLarge(nil, PublicMessage(fmt.Sprintf("value %q exceeds size limit of %s", name, limits.MaxValueBytes)))
+		}
+		m.Values[name] = append(m.Values[name], buf.String())
+		return nil
+	}
+
+	n, err := io.CopyN(&buf, limited, int64(limits.MemoryThreshold)+1)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return multipartError(err, limits, body)
+	}
+	if n > int64(limits.MaxPartBytes) {
+		return partTooLarge()
+	}
+
+	file := &MultipartFile{Filename: part.FileName(), Header: part.Header, Size: n}
+	if n <= int64(limits.MemoryThreshold) {
+		file.content = buf.Bytes()
+		m.Files[name] = append(m.Files[name], file)
+		return nil
+	}
+
+	tmp, err := m.createTemp(limits.TempDir)
+	if err != nil {
+		return ServerError(err, "upload storage failure")
+	}
+	file.path = tmp.Name()
+	rest, err := io.Copy(tmp, io.MultiReader(&buf, limited))
+	closeErr := tmp.Close()
+	if err != nil {
+		var writeErr *os.PathError
+		if errors.As(err, &writeErr) {
+			return ServerError(err, "upload storage failure")
+		}
+		return multipartError(err, limits, body)
+	} else if closeErr != nil {
+		return ServerError(closeErr, "upload storage failure")
+	}
+	if rest > int64(limits.MaxPartBytes) {
+		return partTooLarge()
+	}
+	file.Size = rest
+	m.Files[name] = append(m.Files[name], file)
+	return nil
+}
+
+func multipartError(err error, limits MultipartLimits, body *maxBytesBody) error {
+	if body.exceeded {
+		return tooLarge(err, PublicMessage(fmt.Sprintf("request body exceeds size limit of %s", limits.MaxTotalBytes)))
+	}
+	return BadRequest(err, "malformed multipart body")
+}
+
+// maxBytesBody records whether the request body limit was hit, because mime/multipart does not
+// always forward the underlying error, e.g. when a part's header is truncated.
+type maxBytesBody struct {
+	io.ReadCloser
+	exceeded bool
+}
+
+func (b *maxBytesBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		b.exceeded = true
+	}
+	return n, err
+}
+
+func tooLarge(err error, public PublicMessage) error {
+	return logutil.Severity(Err(err, http.StatusRequestEntityTooLarge, public), slog.LevelWarn)
+}
@@ -0,0 +1,118 @@
+package httpp
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func multipartTestRequest(t *testing.T, fileSize int) *http.Request {
+	return multipartValueRequest(t, "cat", fileSize)
+}
+
+func multipartValueRequest(t *testing.T, title string, fileSize int) *http.Request {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	require.NoError(t, mw.WriteField("title", title))
+	fw, err := mw.CreateFormFile("image", "cat.jpg")
+	require.NoError(t, err)
+	_, err = fw.Write([]byte(strings.Repeat("x", fileSize)))
+	require.NoError(t, err)
+	require.NoError(t, mw.Close())
+	r := httptest.NewRequest(http.MethodPost, "/", &body)
+	r.Header.Set("Content-Type", mw.FormDataContentType())
+	return r
+}
+
+func TestParseMultipart(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	req := multipartTestRequest(t, 100).WithContext(ctx)
+	m, err := ParseMultipart(req, MultipartLimits{MemoryThreshold: 10, TempDir: t.TempDir()})
+	r.NoError(err)
+	a.Equal([]string{"cat"}, m.Values["title"])
+	r.Len(m.Files["image"], 1)
+	file := m.Files["image"][0]
+	a.Equal("cat.jpg", file.Filename)
+	a.EqualValues(100, file.Size)
+	r.NotEmpty(file.path)
+
+	f, err := file.Open()
+	r.NoError(err)
+	content, err := io.ReadAll(f)
+	r.NoError(err)
+	r.NoError(f.Close())
+	a.Equal(strings.Repeat("x", 100), string(content))
+
+	cancel() // request ends
+	a.Eventually(func() bool {
+		_, err := os.Stat(file.path)
+		return os.IsNotExist(err)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestParseMultipart_Limits(t *testing.T) {
+	tests := []struct {
+		name        string
+		limits      MultipartLimits
+		wantMessage PublicMessage
+	}{
+		{name: "part", limits: MultipartLimits{MaxPartBytes: 50},
+			wantMessage: `part "image" exceeds size limit of 50B`},
+		{name: "total", limits: MultipartLimits{MaxTotalBytes: 200},
+			wantMessage: "request body exceeds size limit of 200B"},
+		{name: "count", limits: MultipartLimits{MaxParts: 1},
+			wantMessage: "multipart body exceeds limit of 1 parts"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.limits.TempDir = t.TempDir()
+			_, err := ParseMultipart(multipartTestRequest(t, 100), tt.limits)
+			problem := PublicProblem(err)
+			assert.Equal(t, http.StatusRequestEntityTooLarge, problem.Status)
+			assert.Equal(t, tt.wantMessage, problem.Detail)
+		})
+	}
+}
+
+func TestParseMultipart_Values(t *testing.T) {
+	title := strings.Repeat("t", 50)
+	tests := []struct {
+		name        string
+		limits      MultipartLimits
+		wantMessage PublicMessage
+	}{
+		{name: "above memory threshold", limits: MultipartLimits{MemoryThreshold: 10}},
+		{name: "value", limits: MultipartLimits{MemoryThreshold: 10, MaxValueBytes: 20},
+			wantMessage: `value "title" exceeds size limit of 20B`},
+		{name: "part", limits: MultipartLimits{MaxPartBytes: 20},
+			wantMessage: `part "title" exceeds size limit of 20B`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			tt.limits.TempDir = t.TempDir()
+			m, err := ParseMultipart(multipartValueRequest(t, title, 5), tt.limits)
+			if tt.wantMessage == "" {
+				a.NoError(err)
+				a.Equal([]string{title}, m.Values["title"])
+				return
+			}
+			problem := PublicProblem(err)
+			a.Equal(http.StatusRequestEntityTooLarge, problem.Status)
+			a.Equal(tt.wantMessage, problem.Detail)
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package httpp
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RequestIDHeader is the response header that carries the public request ID. It is set by
+// httpmw.NewLogMiddleware and forwarded as problem instance by WriteErrorFor.
+const RequestIDHeader = "X-Request-Id"
+
+// ProblemContentType is the media type of RFC 9457 problem details in JSON format.
+const ProblemContentType = "application/problem+json"
+
+// ProblemType is a URI that identifies a problem type per RFC 9457. Like PublicMessage, it is sent
+// to clients as-is.
+type ProblemType string
+
+const DefaultProblemType ProblemType = "about:blank"
+
+// Problem is the public representation of an error as RFC 9457 problem details object.
+type Problem struct {
+	Type       ProblemType
+	Title      string
+	Status     int
+	Detail     PublicMessage
+	Instance   string
+	Extensions map[string]any
+	Header     http.Header // public response headers, not part of the JSON object
+}
+
+// PublicProblem converts err into problem details. Only information that was explicitly marked as
+// public through Err is included, all other errors are reported as internal server error.
+func PublicProblem(err error) Problem {
+	var httpErr httpError
+	if !errors.As(err, &httpErr) {
+		return Problem{
+			Type:   DefaultProblemType,
+			Title:  http.StatusText(http.StatusInternalServerError),
+			Status: http.StatusInternalServerError,
+		}
+	}
+	p := Problem{
+		Type:   httpErr.problemType,
+		Title:  http.StatusText(httpErr.statusCode),
+		Status: httpErr.statusCode,
+		Detail: httpErr.msg,
+		Header: httpErr.header.Clone(),
+	}
+	if p.Type == "" {
+		p.Type = DefaultProblemType
+	}
+	if len(httpErr.extensions) != 0 {
+		p.Extensions = make(map[string]any, len(httpErr.extensions))
+		for _, ext := range httpErr.extensions {
+			p.Extensions[ext.key] = ext.value
+		}
+	}
+	return p
+}
+
+// MarshalJSON flattens extension members into the problem details object as per RFC 9457.
+func (p Problem) MarshalJSON() ([]byte, error) {
+	m := make(map[string]any, len(p.Extensions)+5)
+	for k, v := range p.Extensions {
+		if !isProblemMember(k) {
+			m[k] = v
+		}
+	}
+	m["type"] = p.Type
+	m["status"] = p.Status
+	if p.Title != "" {
+		m["title"] = p.Title
+	}
+	if p.Detail != "" {
+		m["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		m["instance"] = p.Instance
+	}
+	return json.Marshal(m)
+}
+
+func isProblemMember(key string) bool {
+	switch key {
+	case "type", "title", "status", "detail", "instance":
+		return true
+	}
+	return false
+}
+
+func writeProblem(w http.ResponseWriter, problem Problem) {
+	buf, err := json.Marshal(problem)
+	if err != nil {
+		// An extension member could not be encoded, fall back to the standard members only.
+		problem.Extensions = nil
+		buf, _ = json.Marshal(problem)
+	}
+	setHeaders(w, problem.Header)
+	hdr := w.Header()
+	hdr.Del("Content-Length") // same as http.Error, in case the handler already set it
+	hdr.Set("Content-Type", ProblemContentType)
+	hdr.Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(problem.Status)
+	_, _ = w.Write(buf)
+}
+
+// acceptsProblem checks whether the client prefers problem details over a plain text error. The
+// client must explicitly list a JSON media type, because many clients send "*/*" by default.
+func acceptsProblem(r *http.Request) bool {
+	accept := r.Header.Values("Accept")
+	if len(accept) == 0 {
+		return false
+	}
+	var qJSON, qText float64
+	specJSON, specText := -1, -1
+	explicitJSON := false
+	for _, line := range accept {
+		for _, mediaRange := range strings.Split(line, ",") {
+			mediaType, q := parseMediaRange(mediaRange)
+			// track the most specific match for each candidate, as per RFC 9110 section 12.5.1
+			switch mediaType {
+			case ProblemContentType, "application/json":
+				if specJSON < 2 {
+					qJSON, specJSON, explicitJSON = q, 2, true
+				}
+			case "text/plain":
+				if specText < 2 {
+					qText, specText = q, 2
+				}
+			case "application/*":
+				if specJSON < 1 {
+					qJSON, specJSON = q, 1
+				}
+			case "text/*":
+				if specText < 1 {
+					qText, specText = q, 1
+				}
+			case "*/*":
+				if specJSON < 0 {
+					qJSON, specJSON = q, 0
+				}
+				if specText < 0 {
+					qText, specText = q, 0
+				}
+			}
+		}
+	}
+	return explicitJSON && qJSON > 0 && qJSON >= qText
+}
+
+func parseMediaRange(s string) (mediaType string, q float64) {
+	mediaType, params, _ := strings.Cut(s, ";")
+	mediaType = strings.ToLower(strings.TrimSpace(mediaType))
+	q = 1
+	for _, param := range strings.Split(params, ";") {
+		name, value, _ := strings.Cut(param, "=")
+		if strings.EqualFold(strings.TrimSpace(name), "q") {
+			if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = v
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,142 @@
+package httpp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_acceptsProblem(t *testing.T) {
+	tests := []struct {
+		accept string
+		want   bool
+	}{
+		{accept: "", want: false},
+		{accept: "*/*", want: false},
+		{accept: "text/html,application/xhtml+xml,*/*;q=0.8", want: false},
+		{accept: "application/json", want: true},
+		{accept: "application/problem+json", want: true},
+		{accept: "application/json, text/plain", want: true},
+		{accept: "text/plain, application/json;q=0.5", want: false},
+		{accept: "application/json;q=0, */*", want: false},
+		{accept: "application/json, */*;q=0.1", want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			assert.Equal(t, tt.want, acceptsProblem(r))
+		})
+	}
+}
+
+func TestWriteErrorFor_Problem(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/json")
+	w := httptest.NewRecorder()
+	w.Header().Set(RequestIDHeader, "req-1")
+	WriteErrorFor(w, req, Unprocessable(errors.New("secret"), "invalid widget",
+		WithProblemType("https://example.com/problems/widget"),
+		WithExtension("widget", "w-1")))
+
+	a.Equal(http.StatusUnprocessableEntity, w.Code)
+	a.Equal(ProblemContentType, w.Header().Get("Content-Type"))
+	var body map[string]any
+	r.NoError(json.Unmarshal(w.Body.Bytes(), &body))
+	a.Equal(map[string]any{
+		"type":     "https://example.com/problems/widget",
+		"title":    "Unprocessable Entity",
+		"status":   float64(http.StatusUnprocessableEntity),
+		"detail":   "invalid widget",
+		"instance": "req-1",
+		"widget":   "w-1",
+	}, body)
+}
+
+func TestWriteErrorFor_ProblemInternal(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Accept", "application/problem+json")
+	w := httptest.NewRecorder()
+	WriteErrorFor(w, req, errors.New("secret"))
+
+	a.Equal(http.StatusInternalServerError, w.Code)
+	var body map[string]any
+	r.NoError(json.Unmarshal(w.Body.Bytes(), &body))
+	a.Equal(map[string]any{
+		"type":   "about:blank",
+		"title":  "Internal Server Error",
+		"status": float64(http.StatusInternalServerError),
+	}, body)
+}
+
+func TestWriteError_PlainText(t *testing.T) {
+	a := assert.New(t)
+
+	w := httptest.NewRecorder()
+	WriteError(w, NotFound("no such widget"))
+
+	a.Equal(http.StatusNotFound, w.Code)
+	a.Equal("text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+	a.Equal("no such widget\n", w.Body.String())
+
+	w = httptest.NewRecorder()
+	WriteErrorFor(w, httptest.NewRequest(http.MethodGet, "/", nil), NotFound("no such widget"))
+	a.Equal(http.StatusNotFound, w.Code)
+	a.Equal("text/plain; charset=utf-8", w.Header().Get("Content-Type"))
+}
+
+func TestWriteErrorFor_Header(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		accept     string
+		wantStatus int
+		wantRetry  string
+	}{
+		{name: "rate limit", err: TooManyRequests(1500*time.Millisecond, "slow down"), wantStatus: http.StatusTooManyRequests, wantRetry: "2"},
+		{name: "unavailable problem", err: Unavailable(time.Minute, DefaultMessage), accept: "application/json", wantStatus: http.StatusServiceUnavailable, wantRetry: "60"},
+		{name: "no retry", err: Unavailable(0, DefaultMessage), wantStatus: http.StatusServiceUnavailable},
+		{name: "wrapped", err: fmt.Errorf("quota: %w", TooManyRequests(time.Second, DefaultMessage)), wantStatus: http.StatusTooManyRequests, wantRetry: "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			WriteErrorFor(w, req, tt.err)
+			a.Equal(tt.wantStatus, w.Code)
+			a.Equal(tt.wantRetry, w.Header().Get("Retry-After"))
+		})
+	}
+}
+
+func TestWriteErrorFor_HeaderShared(t *testing.T) {
+	a := assert.New(t)
+	err := Err(nil, http.StatusMethodNotAllowed, DefaultMessage, WithHeader("Allow", "GET"))
+	for range 2 {
+		w := httptest.NewRecorder()
+		WriteErrorFor(w, httptest.NewRequest(http.MethodPost, "/", nil), err)
+		a.Equal([]string{"GET"}, w.Header().Values("Allow"))
+		w.Header()["Allow"][0] = "HEAD" // must not leak into err or the next response
+	}
+	PublicProblem(err).Header.Add("Allow", "PUT")
+	a.Equal([]string{"GET"}, PublicProblem(err).Header.Values("Allow"))
+}
@@ -0,0 +1,76 @@
+package httpp
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"net/http"
+	"strconv"
+)
+
+// ErrorRenderer writes an error as HTTP response. It is invoked by middlewares that act on errors,
+// e.g. httpmw.NewLogMiddleware, after the handler chain returned an error. Implementations must
+// only forward public information, see PublicProblem.
+type ErrorRenderer interface {
+	RenderError(w http.ResponseWriter, r *http.Request, err error)
+}
+
+type ErrorRendererFunc func(w http.ResponseWriter, r *http.Request, err error)
+
+func (f ErrorRendererFunc) RenderError(w http.ResponseWriter, r *http.Request, err error) {
+	f(w, r, err)
+}
+
+// DefaultErrorRenderer negotiates between plain text and problem details, see WriteErrorFor.
+var DefaultErrorRenderer ErrorRenderer = ErrorRendererFunc(WriteErrorFor)
+
+// ProblemRenderer always writes RFC 9457 problem details, regardless of the request's Accept
+// header. It is intended for pure API servers.
+var ProblemRenderer ErrorRenderer = ErrorRendererFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+	problem := PublicProblem(err)
+	problem.Instance = w.Header().Get(RequestIDHeader)
+	writeProblem(w, problem)
+})
+
+// TemplateRenderer renders errors through an HTML template, e.g. for a branded error page. The
+// template receives a Problem, whose Instance field holds the request ID. DefaultErrorRenderer is
+// used as fallback when the template fails to execute.
+func TemplateRenderer(tmpl *template.Template) ErrorRenderer {
+	return ErrorRendererFunc(func(w http.ResponseWriter, r *http.Request, err error) {
+		problem := PublicProblem(err)
+		problem.Instance = w.Header().Get(RequestIDHeader)
+		var buf bytes.Buffer
+		if tmplErr := tmpl.Execute(&buf, problem); tmplErr != nil {
+			DefaultErrorRenderer.RenderError(w, r, err)
+			return
+		}
+		setHeaders(w, problem.Header)
+		hdr := w.Header()
+		hdr.Set("Content-Type", "text/html; charset=utf-8")
+		hdr.Set("X-Content-Type-Options", "nosniff")
+		if PrefersVariableContentLength(w) {
+			hdr.Del("Content-Length")
+		} else {
+			hdr.Set("Content-Length", strconv.Itoa(buf.Len()))
+		}
+		w.WriteHeader(problem.Status)
+		_, _ = w.Write(buf.Bytes())
+	})
+}
+
+type errorRendererKey struct{}
+
+// WithErrorRenderer installs renderer for all requests that are served with the returned context.
+func WithErrorRenderer(ctx context.Context, renderer ErrorRenderer) context.Context {
+	return context.WithValue(ctx, errorRendererKey{}, renderer)
+}
+
+// RenderError writes err through the request's ErrorRenderer, or DefaultErrorRenderer if none was
+// installed through WithErrorRenderer.
+func RenderError(w http.ResponseWriter, r *http.Request, err error) {
+	if renderer, ok := r.Context().Value(errorRendererKey{}).(ErrorRenderer); ok {
+		renderer.RenderError(w, r, err)
+	} else {
+		DefaultErrorRenderer.RenderError(w, r, err)
+	}
+}
@@ -1,29 +1,144 @@
 package httpp
 
 import (
+	"fmt"
+	"log/slog"
 	"net/http"
 	urlpkg "net/url"
+	"slices"
 	"strings"
+	"sync"
+
+	"github.com/authenticvision/util-go/logutil"
 )
 
 var _ Handler = &ServeMux{}
 
 func NewServeMux() *ServeMux {
-	return &ServeMux{next: http.NewServeMux()}
+	return &ServeMux{next: http.NewServeMux(), state: &muxState{}}
 }
 
+// ServeMux wraps http.ServeMux with error forwarding. Routes can be organized into groups that
+// share a path prefix and middlewares, see Group.
+//
+// Unlike http.ServeMux, requests without matching route are returned as errors: 404 Not Found,
+// or 405 Method Not Allowed with an Allow header if the path matches a route for another method.
+// OPTIONS requests without matching route are answered with 204 No Content and an Allow header.
+// These defaults can be replaced via HandleNotFound and HandleMethodNotAllowed.
 type ServeMux struct {
-	next *http.ServeMux
+	next        *http.ServeMux
+	state       *muxState
+	prefix      string
+	middlewares []Middleware // innermost first, as with httpmw.Chain
+}
+
+// Route is a pattern registered on a ServeMux, as reported by ServeMux.Routes.
+type Route struct {
+	Pattern string  // full pattern, including prefixes of groups
+	Handler Handler // as registered, i.e. without group middlewares
+}
+
+// muxState is shared between a ServeMux and its groups.
+type muxState struct {
+	mu               sync.RWMutex
+	routes           []Route
+	methods          []string // of all method-qualified patterns, sorted
+	notFound         Handler
+	methodNotAllowed Handler
 }
 
 func (mux *ServeMux) Handle(pattern string, handler Handler) {
-	mux.next.Handle(pattern, CollectErrors(handler))
+	pattern = joinPattern(mux.prefix, pattern)
+	wrapped := handler
+	for _, middleware := range mux.middlewares {
+		wrapped = middleware.Middleware(wrapped)
+	}
+	mux.next.Handle(pattern, matchedHandler{next: CollectErrors(wrapped)})
+
+	mux.state.mu.Lock()
+	defer mux.state.mu.Unlock()
+	mux.state.routes = append(mux.state.routes, Route{Pattern: pattern, Handler: handler})
+	if method, _, found := strings.Cut(pattern, " "); found && !strings.Contains(method, "/") {
+		mux.state.methods = insertMethod(mux.state.methods, method)
+		if method == http.MethodGet {
+			mux.state.methods = insertMethod(mux.state.methods, http.MethodHead)
+		}
+	}
 }
 
 func (mux *ServeMux) HandleFunc(pattern string, handlerFunc HandlerFunc) {
 	mux.Handle(pattern, handlerFunc)
 }
 
+// HandleNotFound replaces the handler for requests without matching route. The default handler
+// returns a NotFound error. The handler applies to all groups of the mux and is not wrapped by
+// group middlewares.
+func (mux *ServeMux) HandleNotFound(handler Handler) {
+	mux.state.mu.Lock()
+	defer mux.state.mu.Unlock()
+	mux.state.notFound = handler
+}
+
+// HandleMethodNotAllowed replaces the handler for requests whose path matches routes for other
+// methods only. The Allow header is already set when the handler is invoked. The default handler
+// returns a 405 Method Not Allowed error, or answers OPTIONS requests with 204 No Content.
+func (mux *ServeMux) HandleMethodNotAllowed(handler Handler) {
+	mux.state.mu.Lock()
+	defer mux.state.mu.Unlock()
+	mux.state.methodNotAllowed = handler
+}
+
+// Group returns a sub-router that registers routes on mux below prefix. Routes of the group are
+// wrapped by the given middlewares, whereby the last middleware is the outermost, as with
+// httpmw.Chain. Middlewares of enclosing groups wrap those of nested groups.
+func (mux *ServeMux) Group(prefix string, middlewares ...Middleware) *ServeMux {
+	if prefix != "" && (!strings.HasPrefix(prefix, "/") || strings.ContainsAny(prefix, " {}")) {
+		panic(fmt.Sprintf("httpp.ServeMux.Group: invalid prefix %q", prefix))
+	}
+	return &ServeMux{
+		next:        mux.next,
+		state:       mux.state,
+		prefix:      mux.prefix + strings.TrimSuffix(prefix, "/"),
+		middlewares: append(slices.Clone(middlewares), mux.middlewares...),
+	}
+}
+
+// Routes lists all routes of the underlying mux in order of registration, including those of
+// other groups.
+func (mux *ServeMux) Routes() []Route {
+	mux.state.mu.RLock()
+	defer mux.state.mu.RUnlock()
+	return slices.Clone(mux.state.routes)
+}
+
+func insertMethod(methods []string, method string) []string {
+	if i, found := slices.BinarySearch(methods, method); !found {
+		methods = slices.Insert(slices.Clip(methods), i, method) // copy, readers may hold methods
+	}
+	return methods
+}
+
+// joinPattern inserts prefix in front of the path of pattern, i.e. after method and host.
+func joinPattern(prefix, pattern string) string {
+	if prefix == "" {
+		return pattern
+	}
+	method, rest, found := strings.Cut(pattern, " ")
+	if !found {
+		method, rest = "", pattern
+	}
+	rest = strings.TrimLeft(rest, " \t")
+	host, path := "", rest
+	if i := strings.IndexByte(rest, '/'); i > 0 {
+		host, path = rest[:i], rest[i:]
+	}
+	result := host + prefix + path
+	if method != "" {
+		result = method + " " + result
+	}
+	return result
+}
+
 func (mux *ServeMux) ServeErrHTTP(w http.ResponseWriter, r *http.Request) error {
 	if r.RequestURI == "*" {
 		// reject OPTIONS requests, copied from http.ServeMux.ServeHTTP
@@ -33,12 +148,107 @@ func (mux *ServeMux) ServeErrHTTP(w http.ResponseWriter, r *http.Request) error
 		w.WriteHeader(http.StatusBadRequest)
 		return nil
 	}
-	r, errPtr := WithErrorCollector(r)
-	mux.next.ServeHTTP(w, r)
+	// http.ServeMux.Handler does not populate the request's path values, so the request is routed
+	// through ServeHTTP. Its built-in 404 and 405 responses are intercepted by muxWriter.
+	mw := &muxWriter{w: w}
+	rc, errPtr := WithErrorCollector(r)
+	mux.next.ServeHTTP(mw, rc)
+	if mw.unmatched {
+		return mux.serveUnmatched(w, r)
+	}
 	return *errPtr
 }
 
+// matchedHandler unwraps the muxWriter of ServeErrHTTP for registered routes.
+type matchedHandler struct {
+	next http.Handler
+}
+
+func (h matchedHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if mw, ok := w.(*muxWriter); ok {
+		w = mw.w
+	}
+	h.next.ServeHTTP(w, r)
+}
+
+// muxWriter receives responses of http.ServeMux's built-in handlers. Redirects, e.g. to a path
+// with trailing slash, are forwarded, whereas 404 and 405 responses are discarded in favor of
+// serveUnmatched.
+type muxWriter struct {
+	w         http.ResponseWriter
+	header    http.Header
+	unmatched bool
+}
+
+func (mw *muxWriter) Header() http.Header {
+	if mw.header == nil {
+		mw.header = make(http.Header)
+	}
+	return mw.header
+}
+
+func (mw *muxWriter) WriteHeader(statusCode int) {
+	if statusCode == http.StatusNotFound || statusCode == http.StatusMethodNotAllowed {
+		mw.unmatched = true
+		return
+	}
+	setHeaders(mw.w, mw.header)
+	mw.w.WriteHeader(statusCode)
+}
+
+func (mw *muxWriter) Write(p []byte) (int, error) {
+	if mw.unmatched {
+		return len(p), nil
+	}
+	return mw.w.Write(p)
+}
+
+// serveUnmatched handles requests for which http.ServeMux would write a 404 or 405 response.
+func (mux *ServeMux) serveUnmatched(w http.ResponseWriter, r *http.Request) error {
+	mux.state.mu.RLock()
+	notFound, methodNotAllowed := mux.state.notFound, mux.state.methodNotAllowed
+	mux.state.mu.RUnlock()
+
+	allowed := mux.allowedMethods(r)
+	if len(allowed) == 0 {
+		if notFound != nil {
+			return notFound.ServeErrHTTP(w, r)
+		}
+		return NotFound(DefaultMessage)
+	}
+
+	allow := strings.Join(insertMethod(allowed, http.MethodOptions), ", ")
+	if methodNotAllowed != nil {
+		w.Header().Set("Allow", allow)
+		return methodNotAllowed.ServeErrHTTP(w, r)
+	}
+	if r.Method == http.MethodOptions {
+		w.Header().Set("Allow", allow)
+		return NoContent(w)
+	}
+	return logutil.Severity(Err(nil, http.StatusMethodNotAllowed, DefaultMessage, WithHeader("Allow", allow)), slog.LevelWarn)
+}
+
+// allowedMethods probes the mux for each known method to find routes that match the request's
+// path. This is only done for requests without matching route, so it isn't performance critical.
+func (mux *ServeMux) allowedMethods(r *http.Request) []string {
+	mux.state.mu.RLock()
+	methods := mux.state.methods
+	mux.state.mu.RUnlock()
+
+	var allowed []string
+	probe := *r
+	for _, method := range methods {
+		probe.Method = method
+		if _, pattern := mux.next.Handler(&probe); pattern != "" {
+			allowed = append(allowed, method)
+		}
+	}
+	return allowed
+}
+
 // StripPrefix is copied from Go 1.24.5's http.StripPrefix, with error forwarding added.
+// Requests that do not match prefix are returned as NotFound error.
 func StripPrefix(prefix string, h Handler) Handler {
 	if prefix == "" {
 		return h
@@ -55,8 +265,7 @@ func StripPrefix(prefix string, h Handler) Handler {
 			r2.URL.RawPath = rp
 			return h.ServeErrHTTP(w, r2)
 		} else {
-			http.NotFound(w, r)
-			return nil
+			return NotFound(DefaultMessage)
 		}
 	})
 }
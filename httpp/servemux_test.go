@@ -0,0 +1,94 @@
+package httpp
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type servemuxTestMiddleware string
+
+func (m servemuxTestMiddleware) Middleware(next Handler) Handler {
+	return HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		_, _ = io.WriteString(w, string(m)+">")
+		return next.ServeErrHTTP(w, r)
+	})
+}
+
+func TestServeMux_Group(t *testing.T) {
+	a := assert.New(t)
+
+	handler := HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		_, _ = io.WriteString(w, r.PathValue("id"))
+		return nil
+	})
+	mux := NewServeMux()
+	mux.Handle("GET /health", handler)
+	api := mux.Group("/api/", servemuxTestMiddleware("inner"), servemuxTestMiddleware("outer"))
+	v1 := api.Group("/v1", servemuxTestMiddleware("v1"))
+	v1.Handle("GET /items/{id}", handler)
+	v1.Handle("example.com/hosted", handler)
+
+	w := httptest.NewRecorder()
+	a.NoError(mux.ServeErrHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/items/42", nil)))
+	a.Equal("outer>inner>v1>42", w.Body.String())
+
+	var patterns []string
+	for _, route := range mux.Routes() {
+		patterns = append(patterns, route.Pattern)
+	}
+	a.Equal([]string{"GET /health", "GET /api/v1/items/{id}", "example.com/api/v1/hosted"}, patterns)
+}
+
+func TestServeMux_Unmatched(t *testing.T) {
+	a := assert.New(t)
+
+	mux := NewServeMux()
+	mux.HandleFunc("GET /items/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+	mux.HandleFunc("DELETE /items/{id}", func(w http.ResponseWriter, r *http.Request) error {
+		return nil
+	})
+
+	w := httptest.NewRecorder()
+	err := mux.ServeErrHTTP(w, httptest.NewRequest(http.MethodGet, "/other", nil))
+	a.Equal(http.StatusNotFound, PublicProblem(err).Status)
+
+	w = httptest.NewRecorder()
+	err = mux.ServeErrHTTP(w, httptest.NewRequest(http.MethodPost, "/items/1", nil))
+	a.Equal(http.StatusMethodNotAllowed, PublicProblem(err).Status)
+	a.Equal("DELETE, GET, HEAD, OPTIONS", PublicProblem(err).Header.Get("Allow"))
+	a.Empty(w.Header().Get("Allow"), "the error carries the header")
+	a.Zero(w.Body.Len())
+
+	w = httptest.NewRecorder()
+	a.NoError(mux.ServeErrHTTP(w, httptest.NewRequest(http.MethodOptions, "/items/1", nil)))
+	a.Equal(http.StatusNoContent, w.Code)
+	a.Equal("DELETE, GET, HEAD, OPTIONS", w.Header().Get("Allow"))
+}
+
+func TestServeMux_RoutesOnce(t *testing.T) {
+	a := assert.New(t)
+
+	calls := 0
+	mux := NewServeMux()
+	mux.HandleFunc("GET /dir/", func(w http.ResponseWriter, r *http.Request) error {
+		calls++
+		return NotFound("no such file")
+	})
+
+	w := httptest.NewRecorder()
+	err := mux.ServeErrHTTP(w, httptest.NewRequest(http.MethodGet, "/dir/x", nil))
+	a.Equal(1, calls)
+	a.Equal(PublicMessage("no such file"), PublicProblem(err).Detail, "errors of routes are not mistaken for unmatched requests")
+
+	w = httptest.NewRecorder()
+	a.NoError(mux.ServeErrHTTP(w, httptest.NewRequest(http.MethodGet, "/dir", nil)))
+	a.Equal(http.StatusTemporaryRedirect, w.Code)
+	a.Equal("/dir/", w.Header().Get("Location"))
+	a.Equal(1, calls)
+}
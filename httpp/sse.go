@@ -0,0 +1,177 @@
+package httpp
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/authenticvision/util-go/logutil"
+)
+
+// SSEKeepAliveInterval is the interval in which EventStream sends comments to keep idle
+// connections open through proxies and load balancers.
+var SSEKeepAliveInterval = 15 * time.Second
+
+// EventStream writes Server-Sent Events. It is created through ServeSSE, or through SSE, in which
+// case it must be closed before the handler returns. All methods are safe for concurrent use.
+type EventStream struct {
+	w           http.ResponseWriter
+	rc          *http.ResponseController
+	lastEventID string
+
+	mu     sync.Mutex
+	err    error // sticky write error
+	closed bool
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+// ServeSSE starts a Server-Sent Events response, see SSE, and passes the stream to fn. The stream
+// is closed when fn returns, so that no keep-alive is written after the handler returned.
+func ServeSSE(w http.ResponseWriter, r *http.Request, fn func(*EventStream) error) error {
+	s, err := SSE(w, r)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	return fn(s)
+}
+
+// SSE starts a Server-Sent Events response. The response is flushed after each event. This works
+// behind httpmw.NewCompressionMiddleware, which flushes its encoder on each flush. A keep-alive
+// comment is sent every SSEKeepAliveInterval until the stream is closed or the request context is
+// done. The connection's write deadline is cleared, because streams are usually long-lived.
+//
+// The caller must defer Close, because middlewares may finish the response writer once the
+// handler returned, and net/http only cancels the request context afterwards. Prefer ServeSSE.
+func SSE(w http.ResponseWriter, r *http.Request) (*EventStream, error) {
+	rc := http.NewResponseController(w)
+	if err := rc.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return nil, ServerError(err, "SSE write deadline")
+	}
+
+	hdr := w.Header()
+	hdr.Set("Content-Type", "text/event-stream")
+	hdr.Set("Cache-Control", "no-cache")
+	hdr.Set("X-Accel-Buffering", "no") // disables response buffering of nginx
+	hdr.Del("Content-Length")
+	w.WriteHeader(http.StatusOK)
+	if err := rc.Flush(); err != nil {
+		return nil, logutil.Severity(ServerError(err, "SSE flush failure"), slog.LevelWarn)
+	}
+
+	s := &EventStream{
+		w:           w,
+		rc:          rc,
+		lastEventID: r.Header.Get("Last-Event-ID"),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+	go s.keepAlive(r)
+	return s, nil
+}
+
+// LastEventID returns the ID of the last event that a reconnecting client received, as reported
+// via the Last-Event-ID header. It is empty for new connections.
+func (s *EventStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Send writes an event and flushes it to the client. Event and id may be empty, in which case
+// the client dispatches a "message" event and keeps its previous last event ID, respectively.
+// Multi-line data is split into multiple data fields.
+func (s *EventStream) Send(event, id, data string) error {
+	if strings.ContainsAny(event, "\r\n") {
+		panic("httpp.EventStream.Send: event must not contain line breaks")
+	}
+	if strings.ContainsAny(id, "\r\n\x00") {
+		panic("httpp.EventStream.Send: id must not contain line breaks or NUL")
+	}
+	var sb strings.Builder
+	if event != "" {
+		sb.WriteString("event: ")
+		sb.WriteString(event)
+		sb.WriteByte('\n')
+	}
+	if id != "" {
+		sb.WriteString("id: ")
+		sb.WriteString(id)
+		sb.WriteByte('\n')
+	}
+	data = strings.ReplaceAll(data, "\r\n", "\n")
+	for line := range strings.SplitSeq(data, "\n") {
+		sb.WriteString("data: ")
+		sb.WriteString(line)
+		sb.WriteByte('\n')
+	}
+	sb.WriteByte('\n')
+	return s.write(sb.String())
+}
+
+// SendJSON writes an event with data encoded as JSON.
+func (s *EventStream) SendJSON(event, id string, data any) error {
+	buf, err := json.Marshal(data)
+	if err != nil {
+		return ServerError(err, "encoding failure")
+	}
+	return s.Send(event, id, string(buf))
+}
+
+// SetRetry tells the client how long to wait before reconnecting after the connection is lost.
+func (s *EventStream) SetRetry(d time.Duration) error {
+	return s.write("retry: " + strconv.FormatInt(d.Milliseconds(), 10) + "\n\n")
+}
+
+// Close stops the keep-alive goroutine and waits for it to exit. Subsequent writes fail. It is
+// safe to call Close multiple times.
+func (s *EventStream) Close() {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.stop)
+	}
+	s.mu.Unlock()
+	<-s.done
+}
+
+func (s *EventStream) keepAlive(r *http.Request) {
+	defer close(s.done)
+	ticker := time.NewTicker(SSEKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.write(": keep-alive\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+func (s *EventStream) write(msg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return errors.New("httpp.EventStream: write after close")
+	}
+	if s.err != nil {
+		return s.err
+	}
+	if _, err := io.WriteString(s.w, msg); err != nil {
+		// This usually fails with an I/O error when the client disconnects unexpectedly.
+		s.err = logutil.Severity(ServerError(err, "SSE write failure"), slog.LevelWarn)
+	} else if err := s.rc.Flush(); err != nil {
+		s.err = logutil.Severity(ServerError(err, "SSE flush failure"), slog.LevelWarn)
+	}
+	return s.err
+}
@@ -0,0 +1,93 @@
+package httpp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeSSE(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("Last-Event-ID", "41")
+	w := httptest.NewRecorder()
+	var lastEventID string
+	err := ServeSSE(w, req, func(s *EventStream) error {
+		lastEventID = s.LastEventID()
+		r.NoError(s.SetRetry(1500 * time.Millisecond))
+		r.NoError(s.Send("", "", "hello"))
+		r.NoError(s.Send("progress", "42", "line 1\r\nline 2"))
+		return s.SendJSON("done", "", map[string]int{"total": 2})
+	})
+	r.NoError(err)
+
+	a.Equal("41", lastEventID)
+	a.Equal(http.StatusOK, w.Code)
+	a.Equal("text/event-stream", w.Header().Get("Content-Type"))
+	a.Equal("no-cache", w.Header().Get("Cache-Control"))
+	a.True(w.Flushed)
+	a.Equal("retry: 1500\n\n"+
+		"data: hello\n\n"+
+		"event: progress\nid: 42\ndata: line 1\ndata: line 2\n\n"+
+		"event: done\ndata: {\"total\":2}\n\n", w.Body.String())
+}
+
+// sseTestWriter records whether the stream writes after the handler returned.
+type sseTestWriter struct {
+	*httptest.ResponseRecorder
+
+	mu       sync.Mutex
+	returned bool
+	late     bool
+}
+
+func (w *sseTestWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.late = w.late || w.returned
+	return w.ResponseRecorder.Write(p)
+}
+
+func TestServeSSE_KeepAlive(t *testing.T) {
+	a := assert.New(t)
+
+	prev := SSEKeepAliveInterval
+	t.Cleanup(func() { SSEKeepAliveInterval = prev })
+	SSEKeepAliveInterval = time.Millisecond
+
+	w := &sseTestWriter{ResponseRecorder: httptest.NewRecorder()}
+	var stream *EventStream
+	err := ServeSSE(w, httptest.NewRequest(http.MethodGet, "/", nil), func(s *EventStream) error {
+		stream = s
+		time.Sleep(20 * time.Millisecond)
+		return nil
+	})
+	a.NoError(err)
+	w.mu.Lock()
+	w.returned = true
+	body := w.Body.String()
+	w.mu.Unlock()
+	a.Contains(body, ": keep-alive\n\n")
+
+	time.Sleep(20 * time.Millisecond)
+	w.mu.Lock()
+	a.False(w.late, "keep-alive must stop before the handler returns")
+	w.mu.Unlock()
+	a.Error(stream.Send("", "", "too late"))
+	stream.Close() // idempotent
+}
+
+func TestEventStream_Send(t *testing.T) {
+	s, err := SSE(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	require.NoError(t, err)
+	defer s.Close()
+	assert.Panics(t, func() { _ = s.Send("a\nb", "", "") })
+	assert.Panics(t, func() { _ = s.Send("", "1\n", "") })
+}
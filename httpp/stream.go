@@ -0,0 +1,157 @@
+package httpp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"iter"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/authenticvision/util-go/logutil"
+)
+
+// JSONStreamFlushInterval is the maximum time that JSONStream buffers encoded items.
+var JSONStreamFlushInterval = time.Second
+
+// StreamFormat selects the encoding of JSONStream.
+type StreamFormat int
+
+const (
+	// NDJSON writes one JSON value per line as application/x-ndjson.
+	NDJSON StreamFormat = iota
+	// JSONArray writes a single JSON array as application/json.
+	JSONArray
+)
+
+// JSONStream writes the items of seq as they are produced, without buffering the whole response.
+// The status code is sent with the first item. If seq fails before that, the error is returned
+// as-is, so that it is rendered like any other handler error. Errors after the first item can no
+// longer change the status code. They are returned for logging and reported to the client:
+//
+//   - NDJSON: a final line with an object whose only key is "error" holds the problem details
+//     for the error, see PublicProblem.
+//   - JSONArray: the array is left unterminated, so that clients fail to parse the response
+//     instead of mistaking it for a complete result.
+//
+// Output is flushed every JSONStreamFlushInterval, also while seq blocks, which also flushes the
+// encoder of httpmw.NewCompressionMiddleware. Content-Length is never set.
+func JSONStream[T any](w http.ResponseWriter, seq iter.Seq2[T, error], format StreamFormat) error {
+	rc := http.NewResponseController(w)
+	bw := bufio.NewWriter(w)
+	started := false
+
+	var mu sync.Mutex // guards bw, dirty and flushErr against the flush timer below
+	dirty := false
+	var flushErr error // sticky error of the flush timer
+
+	start := func() {
+		hdr := w.Header()
+		if format == NDJSON {
+			hdr.Set("Content-Type", "application/x-ndjson")
+		} else {
+			hdr.Set("Content-Type", "application/json")
+		}
+		hdr.Del("Content-Length")
+		w.WriteHeader(http.StatusOK)
+		if format == JSONArray {
+			_ = bw.WriteByte('[')
+		}
+		started = true
+	}
+
+	// flush requires mu to be held
+	flush := func() error {
+		dirty = false
+		if err := bw.Flush(); err != nil {
+			// This usually fails with an I/O error when the client disconnects unexpectedly.
+			return logutil.Severity(ServerError(err, "JSON stream write failure"), slog.LevelWarn)
+		}
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return logutil.Severity(ServerError(err, "JSON stream flush failure"), slog.LevelWarn)
+		}
+		return nil
+	}
+
+	stopTimer := make(chan struct{})
+	timerDone := make(chan struct{})
+	go func() {
+		defer close(timerDone)
+		ticker := time.NewTicker(JSONStreamFlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				mu.Lock()
+				if dirty && flushErr == nil {
+					flushErr = flush()
+				}
+				mu.Unlock()
+			case <-stopTimer:
+				return
+			}
+		}
+	}()
+	defer func() {
+		close(stopTimer)
+		<-timerDone // no writes after return
+	}()
+
+	// fail reports an error mid-stream, see above
+	fail := func(err error) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if !started {
+			return err
+		}
+		if format == NDJSON {
+			buf, _ := json.Marshal(map[string]any{"error": PublicProblem(err)})
+			_, _ = bw.Write(buf)
+			_ = bw.WriteByte('\n')
+		}
+		return errors.Join(err, flush())
+	}
+
+	for item, err := range seq {
+		if err != nil {
+			return fail(err)
+		}
+		buf, err := json.Marshal(item)
+		if err != nil {
+			// A custom serializer likely returned an error.
+			return fail(ServerError(err, "encoding failure"))
+		}
+
+		mu.Lock()
+		if flushErr != nil {
+			mu.Unlock()
+			return flushErr
+		}
+		if !started {
+			start()
+		} else if format == JSONArray {
+			_ = bw.WriteByte(',')
+		}
+		_, _ = bw.Write(buf)
+		if format == NDJSON {
+			_ = bw.WriteByte('\n')
+		}
+		dirty = true
+		mu.Unlock()
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if flushErr != nil {
+		return flushErr
+	}
+	if !started {
+		start()
+	}
+	if format == JSONArray {
+		_ = bw.WriteByte(']')
+	}
+	return flush()
+}
@@ -0,0 +1,104 @@
+package httpp
+
+import (
+	"errors"
+	"iter"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func streamTestSeq(n int, err error) iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		for i := range n {
+			if !yield(i, nil) {
+				return
+			}
+		}
+		if err != nil {
+			yield(0, err)
+		}
+	}
+}
+
+func TestJSONStream(t *testing.T) {
+	tests := []struct {
+		name     string
+		format   StreamFormat
+		seq      iter.Seq2[int, error]
+		wantBody string
+		wantErr  bool
+	}{
+		{name: "ndjson", format: NDJSON, seq: streamTestSeq(2, nil), wantBody: "0\n1\n"},
+		{name: "array", format: JSONArray, seq: streamTestSeq(2, nil), wantBody: "[0,1]"},
+		{name: "empty array", format: JSONArray, seq: streamTestSeq(0, nil), wantBody: "[]"},
+		{name: "ndjson error", format: NDJSON, seq: streamTestSeq(1, NotFound("gone")), wantErr: true,
+			wantBody: "0\n" + `{"error":{"detail":"gone","status":404,"title":"Not Found","type":"about:blank"}}` + "\n"},
+		{name: "array error", format: JSONArray, seq: streamTestSeq(1, errors.New("fail")), wantErr: true,
+			wantBody: "[0"},
+		{name: "error before first item", format: JSONArray, seq: streamTestSeq(0, errors.New("fail")), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			w := httptest.NewRecorder()
+			err := JSONStream(w, tt.seq, tt.format)
+			if tt.wantErr {
+				a.Error(err)
+			} else {
+				a.NoError(err)
+			}
+			a.Equal(tt.wantBody, w.Body.String())
+		})
+	}
+}
+
+// syncRecorder guards a httptest.ResponseRecorder against the flush timer of JSONStream.
+type syncRecorder struct {
+	mu sync.Mutex
+	*httptest.ResponseRecorder
+}
+
+func (r *syncRecorder) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ResponseRecorder.Write(p)
+}
+
+func (r *syncRecorder) Flush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.ResponseRecorder.Flush()
+}
+
+func (r *syncRecorder) body() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ResponseRecorder.Body.String()
+}
+
+func TestJSONStream_FlushWhileBlocked(t *testing.T) {
+	a := assert.New(t)
+	defer func(interval time.Duration) { JSONStreamFlushInterval = interval }(JSONStreamFlushInterval)
+	JSONStreamFlushInterval = 10 * time.Millisecond
+
+	release := make(chan struct{})
+	seq := func(yield func(int, error) bool) {
+		if !yield(0, nil) {
+			return
+		}
+		<-release // e.g. a slow database cursor
+		yield(1, nil)
+	}
+	w := &syncRecorder{ResponseRecorder: httptest.NewRecorder()}
+	done := make(chan error)
+	go func() { done <- JSONStream(w, seq, NDJSON) }()
+
+	a.Eventually(func() bool { return w.body() == "0\n" }, time.Second, time.Millisecond)
+	close(release)
+	a.NoError(<-done)
+	a.Equal("0\n1\n", w.body())
+}
@@ -0,0 +1,210 @@
+package httpp
+
+import (
+	"context"
+	"encoding"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+)
+
+// TypedFunc is a request handler that operates on decoded requests and responses.
+type TypedFunc[Req, Resp any] func(ctx context.Context, req Req) (Resp, error)
+
+// Empty can be used as Req for requests without parameters, or as Resp to send 204 No Content.
+type Empty struct{}
+
+// Typed adapts fn into a Handler. The request is decoded into Req as follows:
+//
+//   - A JSON request body is decoded via DecodeJSON, if the request has a body.
+//   - Fields tagged with `path:"name"` are set from r.PathValue("name").
+//   - Fields tagged with `query:"name"` are set from the URL's query parameters. Slice fields
+//     receive all values of a parameter.
+//
+// Path and query values take precedence over the body. They are parsed according to the field's
+// type, which can be a string, bool, integer, float, encoding.TextUnmarshaler, or a pointer or
+// slice thereof. Req is validated after all fields are set, see Validator.
+// The response is encoded via JSON, unless Resp is Empty. Errors returned by fn are passed on
+// as-is, and should be created through Err or its convenience functions.
+func Typed[Req, Resp any](fn TypedFunc[Req, Resp]) *TypedHandler[Req, Resp] {
+	return &TypedHandler[Req, Resp]{Func: fn, params: typedParams(reflect.TypeFor[Req]())}
+}
+
+var _ Handler = &TypedHandler[Empty, Empty]{}
+
+// TypedHandler is created through Typed.
+type TypedHandler[Req, Resp any] struct {
+	Func   TypedFunc[Req, Resp]
+	Decode DecodeOptions
+	params []typedParam
+}
+
+func (h *TypedHandler[Req, Resp]) ServeErrHTTP(w http.ResponseWriter, r *http.Request) error {
+	var req Req
+	if r.Body != nil && r.Body != http.NoBody && r.ContentLength != 0 {
+		if err := decodeJSON(r, h.Decode, &req); err != nil {
+			return err
+		}
+	}
+	reqValue := reflect.ValueOf(&req).Elem()
+	for _, p := range h.params {
+		if err := p.set(reqValue, r); err != nil {
+			return err
+		}
+	}
+	if err := validate(&req); err != nil {
+		return err
+	}
+
+	resp, err := h.Func(r.Context(), req)
+	if err != nil {
+		return err
+	}
+	if _, ok := any(resp).(Empty); ok {
+		return NoContent(w)
+	}
+	return JSON(w, resp)
+}
+
+type typedParamSource int
+
+const (
+	typedParamPath typedParamSource = iota
+	typedParamQuery
+)
+
+func (s typedParamSource) String() string {
+	switch s {
+	case typedParamPath:
+		return "path parameter"
+	case typedParamQuery:
+		return "query parameter"
+	default:
+		return "parameter"
+	}
+}
+
+type typedParam struct {
+	source typedParamSource
+	name   string
+	index  []int
+}
+
+func typedParams(t reflect.Type) []typedParam {
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []typedParam
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() {
+			continue
+		}
+		for source, tag := range []string{typedParamPath: "path", typedParamQuery: "query"} {
+			if name, ok := field.Tag.Lookup(tag); ok {
+				if len(field.Index) > 1 && t.FieldByIndex(field.Index[:len(field.Index)-1]).Type.Kind() == reflect.Pointer {
+					panic(fmt.Sprintf("httpp.Typed: %s field %s is promoted through an embedded pointer", tag, field.Name))
+				}
+				if !canParseParam(field.Type, true) {
+					panic(fmt.Sprintf("httpp.Typed: unsupported type %v for %s field %s", field.Type, tag, field.Name))
+				}
+				params = append(params, typedParam{source: typedParamSource(source), name: name, index: field.Index})
+			}
+		}
+	}
+	return params
+}
+
+func (p typedParam) set(req reflect.Value, r *http.Request) error {
+	var values []string
+	switch p.source {
+	case typedParamPath:
+		if v := r.PathValue(p.name); v != "" {
+			values = []string{v}
+		}
+	case typedParamQuery:
+		values = r.URL.Query()[p.name]
+	}
+	if len(values) == 0 {
+		return nil
+	}
+	if err := parseParam(req.FieldByIndex(p.index), values); err != nil {
+		// name is defined by the application, so it is safe to forward
+		return BadRequest(err, PublicMessage(fmt.Sprintf("invalid %s %q", p.source, p.name)))
+	}
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+
+func canParseParam(t reflect.Type, allowSlice bool) bool {
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		return true
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Bool,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	case reflect.Pointer:
+		return canParseParam(t.Elem(), allowSlice)
+	case reflect.Slice:
+		return allowSlice && canParseParam(t.Elem(), false)
+	default:
+		return false
+	}
+}
+
+func parseParam(v reflect.Value, values []string) error {
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(values[0]))
+	}
+	s := values[0]
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Pointer:
+		elem := reflect.New(v.Type().Elem())
+		if err := parseParam(elem.Elem(), values); err != nil {
+			return err
+		}
+		v.Set(elem)
+	case reflect.Slice:
+		slice := reflect.MakeSlice(v.Type(), len(values), len(values))
+		for i := range values {
+			if err := parseParam(slice.Index(i), values[i:i+1]); err != nil {
+				return err
+			}
+		}
+		v.Set(slice)
+	default:
+		// unreachable, types are checked by canParseParam
+		return fmt.Errorf("unsupported type %v", v.Type())
+	}
+	return nil
+}
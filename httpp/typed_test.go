@@ -0,0 +1,56 @@
+package httpp
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type typedTestRequest struct {
+	ID    int      `path:"id" json:"-"`
+	Tags  []string `query:"tag" json:"-"`
+	Limit *uint    `query:"limit" json:"-"`
+	Name  string   `json:"name"`
+}
+
+type typedTestResponse struct {
+	ID    int      `json:"id"`
+	Tags  []string `json:"tags"`
+	Limit uint     `json:"limit"`
+	Name  string   `json:"name"`
+}
+
+func TestTyped(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	mux := http.NewServeMux()
+	mux.Handle("PUT /items/{id}", NeverErrors(Typed(func(ctx context.Context, req typedTestRequest) (typedTestResponse, error) {
+		return typedTestResponse{ID: req.ID, Tags: req.Tags, Limit: *req.Limit, Name: req.Name}, nil
+	})))
+
+	req := httptest.NewRequest(http.MethodPut, "/items/42?tag=a&tag=b&limit=7", strings.NewReader(`{"name":"foo"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	r.Equal(http.StatusOK, w.Code)
+	a.JSONEq(`{"id":42,"tags":["a","b"],"limit":7,"name":"foo"}`, w.Body.String())
+}
+
+func TestTyped_InvalidParam(t *testing.T) {
+	r := require.New(t)
+
+	h := Typed(func(ctx context.Context, req typedTestRequest) (Empty, error) {
+		return Empty{}, nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/?limit=-1", nil)
+	err := h.ServeErrHTTP(httptest.NewRecorder(), req)
+	r.Error(err)
+	r.Equal(http.StatusBadRequest, PublicProblem(err).Status)
+	r.EqualValues(`invalid query parameter "limit"`, PublicProblem(err).Detail)
+}
@@ -57,7 +57,9 @@ func (k Kafka) NewConsumer(config ConsumerConfig) (*Consumer, error) {
 // interface than sarama's consumer.Consume(). Mainly this means consumption
 // will abort if the consumerFn returns an error.
 // This expects autocommit to be enabled. Note that consumerFn will be called
-// concurrently (but sequentially for each partition).
+// concurrently (but sequentially for each partition). Each message continues the W3C trace of
+// its producer, see InjectTraceContext, which is attached to consumerFn's context. Its IDs are
+// logged via logutil.ContextAttrs for records that are logged with that context.
 func (c *Consumer) Consume(ctx context.Context, consumerFn func(context.Context, *sarama.ConsumerMessage) error) error {
 	log := logutil.FromContext(ctx)
 	ctx, cancel := context.WithCancelCause(ctx)
@@ -75,16 +77,17 @@ func (c *Consumer) Consume(ctx context.Context, consumerFn func(context.Context,
 						log.Debug("message channel was closed")
 						return nil
 					}
+					msgCtx := continueTrace(ctx, message)
 					scope := kafkaScope.Sub(slog.String("key", string(message.Key)))
 					log := scope.Log(log)
-					log.Debug("received message")
+					log.DebugContext(msgCtx, "received message")
 
-					err := consumerFn(logutil.WithLogContext(ctx, log), message)
+					err := consumerFn(logutil.WithLogContext(msgCtx, log), message)
 					if err != nil {
 						cancel(scope.Err(err, "process message"))
 						return nil
 					}
-					log.Debug("processed message")
+					log.DebugContext(msgCtx, "processed message")
 
 					// This only marks a message (actually an offset) as ready
 					// to be commited. This will be picked up by autocommit.
@@ -1,6 +1,8 @@
 package kafka
 
 import (
+	"context"
+
 	"github.com/IBM/sarama"
 	"github.com/authenticvision/util-go/kafka/murmur2"
 )
@@ -43,3 +45,13 @@ func (k Kafka) NewProducer(config ProducerConfig) (*Producer, error) {
 		Topic:    topic,
 	}, nil
 }
+
+// SendMessage sends msg to the producer's topic, unless msg has a topic already. The W3C trace of
+// ctx is propagated to consumers, see InjectTraceContext.
+func (p *Producer) SendMessage(ctx context.Context, msg *sarama.ProducerMessage) (partition int32, offset int64, err error) {
+	if msg.Topic == "" {
+		msg.Topic = p.Topic
+	}
+	InjectTraceContext(ctx, msg)
+	return p.Producer.SendMessage(msg)
+}
@@ -0,0 +1,53 @@
+package kafka
+
+import (
+	"context"
+	"slices"
+
+	"github.com/IBM/sarama"
+	"github.com/authenticvision/util-go/tracectx"
+)
+
+// InjectTraceContext adds record headers to msg that propagate the W3C trace of ctx to consumers,
+// see tracectx. Consumer.Consume continues the trace when processing msg.
+func InjectTraceContext(ctx context.Context, msg *sarama.ProducerMessage) {
+	span, ok := tracectx.FromContext(ctx)
+	if !ok || !span.IsValid() {
+		return
+	}
+	msg.Headers = setHeader(msg.Headers, tracectx.TraceparentHeader, span.Traceparent())
+	if span.State != "" {
+		msg.Headers = setHeader(msg.Headers, tracectx.TracestateHeader, span.State)
+	} else {
+		msg.Headers = slices.DeleteFunc(msg.Headers, func(h sarama.RecordHeader) bool {
+			return string(h.Key) == tracectx.TracestateHeader
+		})
+	}
+}
+
+func setHeader(headers []sarama.RecordHeader, key, value string) []sarama.RecordHeader {
+	for i, h := range headers {
+		if string(h.Key) == key {
+			headers[i].Value = []byte(value)
+			return headers
+		}
+	}
+	return append(headers, sarama.RecordHeader{Key: []byte(key), Value: []byte(value)})
+}
+
+// continueTrace continues the trace of a consumed message, see tracectx.Continue.
+func continueTrace(ctx context.Context, message *sarama.ConsumerMessage) context.Context {
+	var traceparent, tracestate string
+	for _, h := range message.Headers {
+		if h == nil {
+			continue
+		}
+		switch string(h.Key) {
+		case tracectx.TraceparentHeader:
+			traceparent = string(h.Value)
+		case tracectx.TracestateHeader:
+			tracestate = string(h.Value)
+		}
+	}
+	return tracectx.Continue(ctx, traceparent, tracestate)
+}
@@ -0,0 +1,61 @@
+package kafka
+
+import (
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/authenticvision/util-go/tracectx"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTraceRoundTrip(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	span := tracectx.New()
+	span.State = "vendor=value"
+	msg := &sarama.ProducerMessage{Headers: []sarama.RecordHeader{
+		{Key: []byte("other"), Value: []byte("kept")},
+		{Key: []byte(tracectx.TraceparentHeader), Value: []byte("stale")},
+	}}
+	InjectTraceContext(tracectx.WithSpanContext(t.Context(), span), msg)
+	r.Len(msg.Headers, 3) // traceparent is replaced, tracestate is added
+	a.Equal("kept", string(msg.Headers[0].Value))
+	a.Equal(span.Traceparent(), string(msg.Headers[1].Value))
+
+	// consumed messages carry the producer's headers as pointers
+	consumed := &sarama.ConsumerMessage{Headers: []*sarama.RecordHeader{nil}}
+	for i := range msg.Headers {
+		consumed.Headers = append(consumed.Headers, &msg.Headers[i])
+	}
+	child, ok := tracectx.FromContext(continueTrace(t.Context(), consumed))
+	r.True(ok)
+	a.Equal(span.TraceID, child.TraceID)
+	a.NotEqual(span.SpanID, child.SpanID)
+	a.Equal(span.State, child.State)
+
+	fresh := &sarama.ProducerMessage{}
+	InjectTraceContext(tracectx.WithSpanContext(t.Context(), span), fresh)
+	a.Len(fresh.Headers, 2)
+
+	// a stale tracestate is removed for spans without state
+	span.State = ""
+	InjectTraceContext(tracectx.WithSpanContext(t.Context(), span), msg)
+	r.Len(msg.Headers, 2)
+	a.Equal("kept", string(msg.Headers[0].Value))
+	a.Equal(tracectx.TraceparentHeader, string(msg.Headers[1].Key))
+}
+
+func TestInjectTraceContext_NoSpan(t *testing.T) {
+	a := assert.New(t)
+
+	msg := &sarama.ProducerMessage{}
+	InjectTraceContext(t.Context(), msg)
+	a.Nil(msg.Headers)
+
+	// without headers, a new trace is started
+	span, ok := tracectx.FromContext(continueTrace(t.Context(), &sarama.ConsumerMessage{}))
+	a.True(ok)
+	a.True(span.IsValid())
+}
@@ -68,6 +68,10 @@ func (s stackValue) WriteTableTo(w io.Writer) error {
 // information is not exposed through standard runtime interfaces.
 func (s stackValue) WriteStandardTo(w io.Writer) error {
 	_, _ = fmt.Fprintln(w, "goroutine:") // just in case any tooling eats the first line
+	return s.writeStandardFrames(w)
+}
+
+func (s stackValue) writeStandardFrames(w io.Writer) error {
 	frames := runtime.CallersFrames(s.pcs)
 	for {
 		frame, more := frames.Next()
@@ -83,6 +87,13 @@ func (s stackValue) WriteStandardTo(w io.Writer) error {
 	return nil
 }
 
+// String returns the stack in the format of WriteStandardTo.
+func (s stackValue) String() string {
+	w := &strings.Builder{}
+	_ = s.WriteStandardTo(w) // strings.Builder does not fail
+	return w.String()
+}
+
 // MarshalJSON is implemented specifically for slog.JSONHandler, which always calls json.Marshal on
 // slog attributes of kind Any. This would otherwise log an empty object (no public values).
 func (s stackValue) MarshalJSON() ([]byte, error) {
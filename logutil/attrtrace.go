@@ -0,0 +1,8 @@
+package logutil
+
+// TraceIDKey and SpanIDKey identify the distributed trace of a log record as hex strings, as in
+// W3C Trace Context. Collector formats such as FormatDatadog map them to their native fields.
+const (
+	TraceIDKey = "trace_id"
+	SpanIDKey  = "span_id"
+)
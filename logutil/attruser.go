@@ -14,3 +14,19 @@ type UserValue struct {
 	Name  string `json:"name,omitempty"`
 	Email string `json:"email,omitempty"`
 }
+
+// LogValue omits empty fields like the JSON encoding, and exposes fields to redaction of keys
+// such as "usr.email".
+func (u UserValue) LogValue() slog.Value {
+	attrs := make([]slog.Attr, 0, 3)
+	if u.ID != "" {
+		attrs = append(attrs, slog.String("id", u.ID))
+	}
+	if u.Name != "" {
+		attrs = append(attrs, slog.String("name", u.Name))
+	}
+	if u.Email != "" {
+		attrs = append(attrs, slog.String("email", u.Email))
+	}
+	return slog.GroupValue(attrs...)
+}
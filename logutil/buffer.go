@@ -0,0 +1,158 @@
+package logutil
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// RequestBufferSize is the number of records below the log level that NewRequestBuffer retains
+// per request. It is set from Config.RequestBuffer by Config.InstallForProcess, zero disables
+// buffering.
+var RequestBufferSize = 0
+
+// RequestBufferLevel is the lowest level of records that request buffers retain.
+var RequestBufferLevel = slog.LevelDebug
+
+// RequestBuffer retains records that are below the log level in a bounded ring, so that the full
+// context of a failed request can be logged after the fact. A nil *RequestBuffer is valid and
+// does nothing.
+type RequestBuffer struct {
+	mu      sync.Mutex
+	entries []bufferedRecord // ring of at most RequestBufferSize entries
+	next    int              // index of the oldest entry once the ring is full
+	dropped int
+	flushed bool // records are passed through after Flush
+	done    bool // records are neither retained nor passed through after Discard
+}
+
+type bufferedRecord struct {
+	ctx     context.Context
+	handler slog.Handler // carries the attributes and groups of the logger the record was sent to
+	record  slog.Record
+}
+
+// NewRequestBuffer returns a logger that retains records below its level in a RequestBuffer.
+// Records at ERROR level or above flush the buffer automatically. The caller must call Flush when
+// the request fails, and Discard otherwise. If RequestBufferSize is zero, log is returned as-is.
+func NewRequestBuffer(log *slog.Logger) (*slog.Logger, *RequestBuffer) {
+	size := RequestBufferSize
+	if size <= 0 {
+		return log, nil
+	}
+	buf := &RequestBuffer{entries: make([]bufferedRecord, 0, size)}
+	return slog.New(&bufferHandler{next: log.Handler(), buf: buf}), buf
+}
+
+// Flush logs all retained records, marked as buffered, and passes subsequent records through.
+func (b *RequestBuffer) Flush() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if b.flushed || b.done {
+		b.mu.Unlock()
+		return
+	}
+	b.flushed = true
+	entries := append(b.entries[b.next:len(b.entries):len(b.entries)], b.entries[:b.next]...)
+	dropped := b.dropped
+	b.entries = nil
+	b.mu.Unlock()
+
+	if dropped > 0 && len(entries) > 0 {
+		oldest := entries[0]
+		r := slog.NewRecord(oldest.record.Time, RequestBufferLevel, "dropped buffered log records", 0)
+		r.AddAttrs(slog.Int("dropped", dropped), slog.Bool("buffered", true))
+		_ = oldest.handler.Handle(oldest.ctx, r)
+	}
+	for _, e := range entries {
+		e.record.AddAttrs(slog.Bool("buffered", true))
+		_ = e.handler.Handle(e.ctx, e.record)
+	}
+}
+
+// Discard drops all retained records and stops retaining new ones.
+func (b *RequestBuffer) Discard() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.done = true
+	b.entries = nil
+}
+
+// add retains a record and reports whether it should be passed through instead.
+func (b *RequestBuffer) add(ctx context.Context, handler slog.Handler, record slog.Record) (passThrough bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.flushed {
+		return true
+	} else if b.done {
+		return false
+	}
+	// The context is retained without its cancellation, as the request is likely done on Flush.
+	e := bufferedRecord{ctx: context.WithoutCancel(ctx), handler: handler, record: record.Clone()}
+	if len(b.entries) < cap(b.entries) {
+		b.entries = append(b.entries, e)
+	} else {
+		b.entries[b.next] = e
+		b.next = (b.next + 1) % len(b.entries)
+		b.dropped++
+	}
+	return false
+}
+
+func (b *RequestBuffer) retaining() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return !b.done
+}
+
+type bufferHandler struct {
+	next slog.Handler
+	buf  *RequestBuffer
+}
+
+func (h *bufferHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level) || (level >= RequestBufferLevel && h.buf.retaining())
+}
+
+func (h *bufferHandler) Handle(ctx context.Context, record slog.Record) error {
+	if forcedEnabled(ctx) || h.next.Enabled(ctx, record.Level) {
+		if record.Level >= slog.LevelError {
+			h.buf.Flush()
+		}
+		return h.next.Handle(ctx, record)
+	}
+	if h.buf.add(ctx, h.next, record) {
+		return h.next.Handle(ctx, record)
+	}
+	return nil
+}
+
+func (h *bufferHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &bufferHandler{next: h.next.WithAttrs(attrs), buf: h.buf}
+}
+
+func (h *bufferHandler) WithGroup(name string) slog.Handler {
+	return &bufferHandler{next: h.next.WithGroup(name), buf: h.buf}
+}
+
+func (h *bufferHandler) levels() handlerLevels {
+	return levelsOf(h.next)
+}
+
+type forcedEnabledKey struct{}
+
+// withForcedEnabled marks a record as enabled by a handler further up the chain, e.g. by a scope
+// level, so that request buffers pass it through although the level of the next handler is higher.
+func withForcedEnabled(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcedEnabledKey{}, true)
+}
+
+func forcedEnabled(ctx context.Context) bool {
+	forced, _ := ctx.Value(forcedEnabledKey{}).(bool)
+	return forced
+}
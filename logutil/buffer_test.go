@@ -0,0 +1,75 @@
+package logutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestBuffer(t *testing.T) {
+	prev := RequestBufferSize
+	t.Cleanup(func() { RequestBufferSize = prev })
+	RequestBufferSize = 2
+
+	newLogger := func(t *testing.T) (*slog.Logger, *bytes.Buffer) {
+		buf := bytes.NewBuffer(nil)
+		h, err := NewHandlerTo(buf, FormatJSON, slog.LevelInfo)
+		require.NoError(t, err)
+		return slog.New(h).With(slog.String("request_id", "r-1")), buf
+	}
+	messages := func(t *testing.T, buf *bytes.Buffer) (msgs []string) {
+		for line := range strings.Lines(buf.String()) {
+			var entry map[string]any
+			require.NoError(t, json.Unmarshal([]byte(line), &entry))
+			assert.Equal(t, "r-1", entry["request_id"])
+			msgs = append(msgs, entry[slog.MessageKey].(string))
+		}
+		return
+	}
+
+	t.Run("discard", func(t *testing.T) {
+		log, out := newLogger(t)
+		log, buf := NewRequestBuffer(log)
+		log.Debug("debug")
+		log.Info("info")
+		buf.Discard()
+		log.Debug("late")
+		assert.Equal(t, []string{"info"}, messages(t, out))
+	})
+
+	t.Run("flush", func(t *testing.T) {
+		log, out := newLogger(t)
+		log, buf := NewRequestBuffer(log)
+		log.Debug("debug 1")
+		log.Debug("debug 2")
+		log.Debug("debug 3")
+		log.Log(t.Context(), LevelTrace, "trace")
+		buf.Flush()
+		log.Debug("debug 4")
+		assert.Equal(t, []string{"dropped buffered log records", "debug 2", "debug 3", "debug 4"}, messages(t, out))
+	})
+
+	t.Run("error", func(t *testing.T) {
+		log, out := newLogger(t)
+		log, buf := NewRequestBuffer(log)
+		log.Debug("debug")
+		log.Error("error")
+		buf.Discard()
+		assert.Equal(t, []string{"debug", "error"}, messages(t, out))
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		RequestBufferSize = 0
+		log, _ := newLogger(t)
+		buffered, buf := NewRequestBuffer(log)
+		assert.Same(t, log, buffered)
+		assert.Nil(t, buf)
+		buf.Flush()
+		buf.Discard()
+	})
+}
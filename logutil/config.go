@@ -3,27 +3,71 @@ package logutil
 import (
 	"fmt"
 	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
 )
 
 var DefaultConfig = Config{
-	Level:  Level(slog.LevelInfo),
-	Format: FormatText,
+	Level:    Level(slog.LevelInfo),
+	Format:   FormatText,
+	Sampling: SamplingOptions{Interval: time.Second},
 }
 
 type Config struct {
-	Level  Level  `usage:"TRACE, DEBUG, INFO, WARN, or ERROR"`
-	Format Format `usage:"TEXT or JSON"`
+	Level     Level     `usage:"TRACE, DEBUG, INFO, WARN, or ERROR"`
+	Levels    LevelSpec `usage:"per-scope levels, e.g. kafka=DEBUG,grpc=WARN. a leading default level overrides level"`
+	Format    Format    `usage:"TEXT, JSON, LOGFMT, DATADOG, GCP, or ECS"`
+	LevelFile string    `usage:"optional file to watch for a level spec that overrides the configured levels at runtime"`
+
+	RequestBuffer int             `usage:"number of records below the log level to retain per request, logged only if the request fails"`
+	Sampling      SamplingOptions // see NewSamplingHandler
+	ErrorChain    bool            `usage:"log errors with a structured chain of types, status codes and severities, and a fingerprint"`
+	ErrorStacks   bool            `usage:"capture a stack trace when errors are created through logutil, logged along with the error"`
 }
 
+// NewHandler creates a handler with its own level and per-scope levels, which are initialized
+// from c.Level and c.Levels. Records are sampled as per c.Sampling, and errors are logged with
+// their chain if c.ErrorChain is set, see ErrorChain. Attributes are extracted from contexts
+// through DefaultContextExtractors. The process-wide settings of c are applied by
+// InstallForProcess only.
 func (c Config) NewHandler() (slog.Handler, error) {
-	return NewHandler(c.Format, slog.Level(c.Level))
+	lv := handlerLevels{level: new(slog.LevelVar), scopes: new(atomic.Pointer[map[string]slog.Level])}
+	c.setLevels(lv)
+	return c.newHandler(lv)
+}
+
+func (c Config) newHandler(lv handlerLevels) (slog.Handler, error) {
+	handler, err := newHandlerTo(os.Stderr, c.Format, lv.level, c.ErrorChain, DefaultContextExtractors...)
+	if err != nil {
+		return nil, err
+	}
+	return &levelsHandler{next: NewSamplingHandler(handler, c.Sampling), lv: lv}, nil
+}
+
+func (c Config) setLevels(lv handlerLevels) {
+	level := slog.Level(c.Level)
+	if c.Levels.Default != nil {
+		level = slog.Level(*c.Levels.Default)
+	}
+	lv.level.Set(level)
+	lv.scopes.Store(scopeLevelMap(c.Levels.Scopes))
 }
 
+// InstallForProcess replaces slog.Default with a handler like NewHandler, whose levels are
+// ProcessLevel and the per-scope levels of SetLevelSpec, so that they can be changed at runtime.
+// It applies the process-wide settings of c: ProcessLevel is set to c.Level, and per-scope levels
+// are set to c.Levels. Request buffers are configured through c.RequestBuffer, see
+// NewRequestBuffer, and stacks are captured for errors if c.ErrorStacks is set, see ErrorStacks.
+// It should be called once on startup, before logging from multiple goroutines.
 func (c Config) InstallForProcess() error {
-	handler, err := c.NewHandler()
+	handler, err := c.newHandler(processLevels)
 	if err != nil {
 		return fmt.Errorf("failed to create log handler: %w", err)
 	}
+	c.setLevels(processLevels)
+	RequestBufferSize = c.RequestBuffer
+	ErrorStacks = c.ErrorStacks
 	slog.SetDefault(slog.New(handler))
 	InstallGoLogShim()
 	return nil
@@ -0,0 +1,44 @@
+package logutil
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_NewHandler(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	prevLevel := ProcessLevel.Level()
+	t.Cleanup(func() { ProcessLevel.Set(prevLevel) })
+	ProcessLevel.Set(slog.LevelInfo)
+
+	debug := Level(slog.LevelDebug)
+	h, err := Config{
+		Level:         Level(slog.LevelWarn),
+		Levels:        LevelSpec{Scopes: map[string]Level{"kafka": debug}},
+		Format:        FormatJSON,
+		RequestBuffer: 10,
+		ErrorChain:    true,
+		ErrorStacks:   true,
+	}.NewHandler()
+	r.NoError(err)
+	other, err := Config{Level: debug, Format: FormatJSON}.NewHandler()
+	r.NoError(err)
+
+	ctx := t.Context()
+	a.False(h.Enabled(ctx, slog.LevelInfo))
+	a.True(h.Enabled(ctx, slog.LevelWarn))
+	a.True(NewScope("kafka").Log(slog.New(h)).Enabled(ctx, slog.LevelDebug))
+	a.True(other.Enabled(ctx, slog.LevelDebug))
+	a.False(NewScope("kafka").Log(slog.New(other)).Enabled(ctx, LevelTrace))
+
+	a.Equal(slog.LevelInfo, ProcessLevel.Level())
+	a.Empty(CurrentLevelSpec().Scopes)
+	a.Zero(RequestBufferSize)
+	a.False(ErrorChain)
+	a.False(ErrorStacks)
+}
@@ -0,0 +1,144 @@
+package logutil
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync/atomic"
+)
+
+// RequestIDKey identifies the request that a log record belongs to. httpmw.NewLogMiddleware and
+// grpcutil's request ID interceptors attach it to the request's context via WithContextAttrs.
+const RequestIDKey = "request_id"
+
+// ContextExtractor returns attributes of request-scoped values in ctx, e.g. a trace ID. It is
+// called for each record that is logged with a context, so it should be cheap.
+type ContextExtractor func(ctx context.Context) []slog.Attr
+
+// DefaultContextExtractors are passed to NewHandler by Config.NewHandler.
+var DefaultContextExtractors = []ContextExtractor{ContextAttrs}
+
+type contextAttrsKey struct{}
+
+// WithContextAttrs attaches attributes to ctx, in addition to the attributes of its parent, which
+// are replaced if they have the same key. Unlike attributes of a logger in ctx, they are logged by
+// any logger via the ContextAttrs extractor, e.g. slog.Default().InfoContext(ctx, ...).
+func WithContextAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	merged := slices.DeleteFunc(slices.Clone(ContextAttrs(ctx)), func(parent slog.Attr) bool {
+		return slices.ContainsFunc(attrs, func(attr slog.Attr) bool { return attr.Key == parent.Key })
+	})
+	return context.WithValue(ctx, contextAttrsKey{}, append(merged, attrs...))
+}
+
+// ContextAttrs is a ContextExtractor for attributes attached through WithContextAttrs.
+func ContextAttrs(ctx context.Context) []slog.Attr {
+	attrs, _ := ctx.Value(contextAttrsKey{}).([]slog.Attr)
+	return attrs
+}
+
+// contextHandler adds attributes of its extractors to records. They are added at the root of the
+// record, also for loggers with groups. Attributes whose key was already added at the root, e.g.
+// via slog.Logger.With, are skipped.
+type contextHandler struct {
+	next       slog.Handler
+	extractors []ContextExtractor
+	keys       []string // of root attributes
+
+	// Once a group is opened, extracted attributes are added to root, and ops are replayed.
+	// The handler of the latest attributes is cached, as records of a request share them.
+	root  slog.Handler
+	ops   []handlerOp
+	cache *atomic.Pointer[contextHandlerCache]
+}
+
+type contextHandlerCache struct {
+	attrs   []slog.Attr
+	handler slog.Handler
+}
+
+// handlerOp is a call of WithGroup or WithAttrs.
+type handlerOp struct {
+	group string
+	attrs []slog.Attr
+}
+
+func (h *contextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if ctx == nil {
+		return h.next.Handle(ctx, record)
+	}
+	var extracted []slog.Attr
+	for _, extract := range h.extractors {
+		extracted = append(extracted, extract(ctx)...)
+	}
+	if len(extracted) == 0 {
+		return h.next.Handle(ctx, record)
+	}
+	keys := slices.Clone(h.keys)
+	if h.root == nil {
+		record.Attrs(func(attr slog.Attr) bool {
+			keys = append(keys, attr.Key)
+			return true
+		})
+	}
+	var attrs []slog.Attr
+	for _, attr := range extracted {
+		if !slices.Contains(keys, attr.Key) {
+			keys = append(keys, attr.Key)
+			attrs = append(attrs, attr)
+		}
+	}
+	if len(attrs) == 0 {
+		return h.next.Handle(ctx, record)
+	}
+	if h.root == nil {
+		record = record.Clone()
+		record.AddAttrs(attrs...)
+		return h.next.Handle(ctx, record)
+	}
+	if cached := h.cache.Load(); cached != nil && slices.EqualFunc(cached.attrs, attrs, slog.Attr.Equal) {
+		return cached.handler.Handle(ctx, record)
+	}
+	next := h.root.WithAttrs(attrs)
+	for _, op := range h.ops {
+		if op.group != "" {
+			next = next.WithGroup(op.group)
+		} else {
+			next = next.WithAttrs(op.attrs)
+		}
+	}
+	h.cache.Store(&contextHandlerCache{attrs: attrs, handler: next})
+	return next.Handle(ctx, record)
+}
+
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	c.next = h.next.WithAttrs(attrs)
+	if h.root == nil {
+		c.keys = slices.Clip(h.keys)
+		for _, attr := range attrs {
+			c.keys = append(c.keys, attr.Key)
+		}
+	} else {
+		c.ops = append(slices.Clip(h.ops), handlerOp{attrs: attrs})
+		c.cache = new(atomic.Pointer[contextHandlerCache])
+	}
+	return &c
+}
+
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	c := *h
+	c.next = h.next.WithGroup(name)
+	if h.root == nil {
+		c.root = h.next
+	}
+	c.ops = append(slices.Clip(h.ops), handlerOp{group: name})
+	c.cache = new(atomic.Pointer[contextHandlerCache])
+	return &c
+}
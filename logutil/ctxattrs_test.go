@@ -0,0 +1,122 @@
+package logutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHandlerTo_ContextExtractors(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	type traceKey struct{}
+	traceExtractor := func(ctx context.Context) []slog.Attr {
+		if id, ok := ctx.Value(traceKey{}).(string); ok {
+			return []slog.Attr{slog.String(TraceIDKey, id)}
+		}
+		return nil
+	}
+
+	buf := bytes.NewBuffer(nil)
+	h, err := NewHandlerTo(buf, FormatJSON, slog.LevelInfo, ContextAttrs, traceExtractor)
+	r.NoError(err)
+	log := slog.New(h)
+
+	ctx := WithContextAttrs(t.Context(), slog.String(RequestIDKey, "r-1"))
+	ctx = WithContextAttrs(ctx, slog.String("tenant", "t-1"))
+	ctx = context.WithValue(ctx, traceKey{}, "0af7651916cd43dd8448eb211c80319c")
+
+	tests := []struct {
+		name string
+		log  func()
+		want map[string]any
+	}{
+		{
+			name: "without context",
+			log:  func() { log.Info("message") },
+			want: map[string]any{},
+		},
+		{
+			name: "with context",
+			log:  func() { log.InfoContext(ctx, "message") },
+			want: map[string]any{RequestIDKey: "r-1", "tenant": "t-1", TraceIDKey: "0af7651916cd43dd8448eb211c80319c"},
+		},
+		{
+			name: "logger attributes take precedence",
+			log: func() {
+				log.With(slog.String(RequestIDKey, "r-2")).WithGroup("g").InfoContext(ctx, "message", slog.String("tenant", "t-2"))
+			},
+			want: map[string]any{RequestIDKey: "r-2", "tenant": "t-1", TraceIDKey: "0af7651916cd43dd8448eb211c80319c",
+				"g": map[string]any{"tenant": "t-2"}},
+		},
+		{
+			name: "nested groups",
+			log: func() {
+				log.WithGroup("a").With(slog.String("x", "1")).WithGroup("b").InfoContext(ctx, "message", slog.String("y", "2"))
+			},
+			want: map[string]any{RequestIDKey: "r-1", "tenant": "t-1", TraceIDKey: "0af7651916cd43dd8448eb211c80319c",
+				"a": map[string]any{"x": "1", "b": map[string]any{"y": "2"}}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			buf.Reset()
+			tt.log()
+			var entry map[string]any
+			r.NoError(json.Unmarshal(buf.Bytes(), &entry))
+			delete(entry, slog.TimeKey)
+			delete(entry, slog.LevelKey)
+			delete(entry, slog.MessageKey)
+			a.Equal(tt.want, entry)
+		})
+	}
+}
+
+func TestNewHandlerTo_ContextExtractors_Cache(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	buf := bytes.NewBuffer(nil)
+	h, err := NewHandlerTo(buf, FormatJSON, slog.LevelInfo, ContextAttrs)
+	r.NoError(err)
+	log := slog.New(h).WithGroup("g")
+
+	// the handler of a group is rebuilt when the context's attributes change
+	for _, id := range []string{"r-1", "r-1", "r-2", "r-1"} {
+		buf.Reset()
+		log.InfoContext(WithContextAttrs(t.Context(), slog.String(RequestIDKey, id)), "message")
+		var entry map[string]any
+		r.NoError(json.Unmarshal(buf.Bytes(), &entry))
+		a.Equal(id, entry[RequestIDKey])
+	}
+}
+
+func BenchmarkContextHandler(b *testing.B) {
+	h, err := NewHandlerTo(io.Discard, FormatJSON, slog.LevelInfo, ContextAttrs)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ctx := WithContextAttrs(b.Context(), slog.String(RequestIDKey, "r-1"))
+	benchmarks := []struct {
+		name string
+		log  *slog.Logger
+	}{
+		{name: "root", log: slog.New(h).With(slog.String("x", "1"))},
+		{name: "group", log: slog.New(h).WithGroup("a").With(slog.String("x", "1")).WithGroup("b")},
+	}
+	for _, bm := range benchmarks {
+		b.Run(bm.name, func(b *testing.B) {
+			b.ReportAllocs()
+			for b.Loop() {
+				bm.log.InfoContext(ctx, "message", slog.String("y", "2"))
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package logutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// ErrorChain enables logging of ErrKey+".chain" and ErrKey+".fingerprint" in handlers created
+// through NewHandlerTo afterwards, see ErrorLinks and Fingerprint. Config.NewHandler uses
+// Config.ErrorChain instead.
+var ErrorChain = false
+
+// ErrorLink describes an error of an error chain.
+type ErrorLink struct {
+	Type       string         `json:"type"`
+	Message    string         `json:"message,omitempty"` // excludes the message of the wrapped error
+	Attrs      map[string]any `json:"attrs,omitempty"`
+	HTTPStatus int            `json:"http_status,omitempty"`
+	GRPCCode   string         `json:"grpc_code,omitempty"`
+	Severity   string         `json:"severity,omitempty"`
+}
+
+// Errors report their status through these interfaces, which are implemented by httpp.Err and
+// grpcutil.Err.
+type (
+	httpStatusError interface{ StatusCode() int }
+	grpcCodeError   interface{ GRPCCode() string }
+)
+
+// ErrorLinks describes each error along err's chain, from outermost to innermost. The chain ends
+// at a joined error, whose branches are logged as causes, see Destructure.
+func ErrorLinks(err error) []ErrorLink {
+	var links []ErrorLink
+	for err != nil {
+		link := ErrorLink{Type: fmt.Sprintf("%T", err)}
+		var next error
+		if x, ok := err.(interface{ Unwrap() error }); ok {
+			next = x.Unwrap()
+		}
+		if sErr, ok := err.(*scopedError); ok {
+			link.Message = sErr.msg
+			link.Attrs = linkAttrs(sErr)
+		} else if _, ok := err.(interface{ Unwrap() []error }); !ok {
+			link.Message = ownMessage(err, next)
+		}
+		if x, ok := err.(httpStatusError); ok {
+			link.HTTPStatus = x.StatusCode()
+		}
+		if x, ok := err.(grpcCodeError); ok {
+			link.GRPCCode = x.GRPCCode()
+		}
+		if x, ok := err.(slog.Leveler); ok {
+			level := Level(x.Level())
+			link.Severity = level.String()
+		}
+		links = append(links, link)
+		err = next
+	}
+	return links
+}
+
+// Fingerprint hashes the types and messages of err's chain. Attributes are not part of the
+// fingerprint, so that failures which only differ by e.g. an ID share a fingerprint.
+func Fingerprint(err error) string {
+	return fingerprint(ErrorLinks(err))
+}
+
+func fingerprint(links []ErrorLink) string {
+	h := sha256.New()
+	for _, link := range links {
+		_, _ = fmt.Fprintf(h, "%s\x00%s\n", link.Type, link.Message)
+	}
+	return hex.EncodeToString(h.Sum(nil)[:8])
+}
+
+// ownMessage strips the wrapped error's message from err's message, e.g. "read config" from
+// fmt.Errorf("read config: %w", err).
+func ownMessage(err, next error) string {
+	msg := err.Error()
+	if next == nil {
+		return msg
+	}
+	msg, ok := strings.CutSuffix(msg, next.Error())
+	if !ok {
+		return err.Error()
+	}
+	return strings.TrimRight(msg, ": ")
+}
+
+func linkAttrs(sErr *scopedError) map[string]any {
+	if len(sErr.attrs) == 0 {
+		return nil
+	}
+	var groups []string
+	if sErr.group != "" {
+		groups = []string{sErr.group}
+	}
+	m := attrsMap(groups, sErr.attrs)
+	if len(m) == 0 {
+		return nil
+	}
+	if sErr.group != "" {
+		return map[string]any{sErr.group: m}
+	}
+	return m
+}
+
+// attrsMap converts attributes for JSON encoding, and redacts them like redactHandler. Stacks are
+// omitted, because they are logged under StackKey.
+func attrsMap(groups []string, attrs []slog.Attr) map[string]any {
+	m := make(map[string]any, len(attrs))
+	for _, attr := range attrs {
+		attr, _ = redactAttr(groups, attr)
+		value := attr.Value.Resolve()
+		if _, ok := value.Any().(stackValue); ok {
+			continue
+		}
+		if value.Kind() == slog.KindGroup {
+			if attr.Key == "" {
+				for k, v := range attrsMap(groups, value.Group()) {
+					m[k] = v
+				}
+			} else {
+				m[attr.Key] = attrsMap(append(slices.Clip(groups), attr.Key), value.Group())
+			}
+		} else if attr.Key != "" {
+			m[attr.Key] = value.Any()
+		}
+	}
+	return m
+}
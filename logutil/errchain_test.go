@@ -0,0 +1,72 @@
+package logutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type statusError struct {
+	err  error
+	code int
+}
+
+func (e statusError) Error() string   { return fmt.Sprintf("http status %d: %v", e.code, e.err) }
+func (e statusError) Unwrap() error   { return e.err }
+func (e statusError) StatusCode() int { return e.code }
+
+func TestErrorLinks(t *testing.T) {
+	a := assert.New(t)
+
+	newErr := func(id string) error {
+		scope := NewScope("db", slog.String("table", "users"))
+		inner := scope.Err(fmt.Errorf("no rows"), "lookup failed", slog.String("id", id), slog.String("token", "t-1"))
+		return Severity(statusError{err: fmt.Errorf("load user: %w", inner), code: 404}, slog.LevelWarn)
+	}
+	err := newErr("u-1")
+
+	a.Equal([]ErrorLink{
+		{Type: "*logutil.severityError", Severity: "WARN"},
+		{Type: "logutil.statusError", Message: "http status 404", HTTPStatus: 404},
+		{Type: "*fmt.wrapError", Message: "load user"},
+		{Type: "*logutil.scopedError", Message: "lookup failed", Attrs: map[string]any{
+			"db": map[string]any{"table": "users", "id": "u-1", "token": Redacted},
+		}},
+		{Type: "*errors.errorString", Message: "no rows"},
+	}, ErrorLinks(err))
+
+	a.Len(Fingerprint(err), 16)
+	a.Equal(Fingerprint(err), Fingerprint(newErr("u-2")), "attributes must not change the fingerprint")
+	a.NotEqual(Fingerprint(err), Fingerprint(fmt.Errorf("load user: %w", err)))
+}
+
+func TestNewHandler_ErrorChain(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	ErrorChain = true
+	t.Cleanup(func() { ErrorChain = false })
+
+	buf := bytes.NewBuffer(nil)
+	h, err := NewHandlerTo(buf, FormatJSON, slog.LevelInfo)
+	r.NoError(err)
+	testErr := NewError(nil, "test error", slog.String("foo", "bar"))
+	slog.New(h).Info("message", Err(testErr))
+
+	var entry struct {
+		Error       string      `json:"error"`
+		Chain       []ErrorLink `json:"error.chain"`
+		Fingerprint string      `json:"error.fingerprint"`
+	}
+	r.NoError(json.Unmarshal(buf.Bytes(), &entry))
+	a.Equal("test error", entry.Error)
+	a.Equal([]ErrorLink{
+		{Type: "*logutil.scopedError", Message: "test error", Attrs: map[string]any{"foo": "bar"}},
+	}, entry.Chain)
+	a.Equal(Fingerprint(testErr), entry.Fingerprint)
+}
@@ -13,14 +13,18 @@ var _ pflag.Value = op.Ref(FormatText)
 type Format string
 
 const (
-	FormatText Format = "TEXT"
-	FormatJSON Format = "JSON"
+	FormatText    Format = "TEXT"
+	FormatJSON    Format = "JSON"
+	FormatLogfmt  Format = "LOGFMT"
+	FormatDatadog Format = "DATADOG"
+	FormatGCP     Format = "GCP"
+	FormatECS     Format = "ECS"
 )
 
 func (f *Format) UnmarshalText(text []byte) error {
 	format := Format(strings.ToUpper(string(text)))
 	switch format {
-	case FormatText, FormatJSON:
+	case FormatText, FormatJSON, FormatLogfmt, FormatDatadog, FormatGCP, FormatECS:
 		*f = format
 		return nil
 	}
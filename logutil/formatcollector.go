@@ -0,0 +1,237 @@
+package logutil
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"log/slog"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// GCPProjectID qualifies trace IDs in FormatGCP, which Cloud Logging requires for linking logs to
+// Cloud Trace. It defaults to the GOOGLE_CLOUD_PROJECT environment variable.
+var GCPProjectID = os.Getenv("GOOGLE_CLOUD_PROJECT")
+
+const ecsVersion = "8.11.0"
+
+// logfmtAttrReplacer is LevelAttrReplacer, plus stack traces as multi-line string.
+func logfmtAttrReplacer(groups []string, a slog.Attr) slog.Attr {
+	if stack, ok := a.Value.Any().(stackValue); ok && a.Key == StackKey {
+		return slog.String(StackKey, stack.String())
+	}
+	return LevelAttrReplacer(groups, a)
+}
+
+// datadogAttrReplacer maps attributes to Datadog's reserved and standard attributes:
+// https://docs.datadoghq.com/logs/log_configuration/attributes_naming_convention/
+func datadogAttrReplacer(groups []string, a slog.Attr) slog.Attr {
+	// Errors and stacks are mapped in any group, including error.causes.N of destructured errors.
+	switch a.Key {
+	case ErrKey:
+		if err, ok := a.Value.Any().(error); ok {
+			return inline(slog.String("error.kind", errorKind(err)), slog.String("error.message", err.Error()))
+		}
+	case StackKey:
+		if stack, ok := a.Value.Any().(stackValue); ok {
+			return slog.String("error.stack", stack.String())
+		}
+	}
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		a.Key = "timestamp"
+	case slog.MessageKey:
+		a.Key = "message"
+	case slog.LevelKey:
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String("status", datadogStatus(level))
+		}
+	case TraceIDKey:
+		return slog.String("dd.trace_id", datadogID(a.Value.String()))
+	case SpanIDKey:
+		return slog.String("dd.span_id", datadogID(a.Value.String()))
+	}
+	return a
+}
+
+func datadogStatus(level slog.Level) string {
+	switch {
+	case level >= LevelFatal:
+		return "critical"
+	case level >= slog.LevelError:
+		return "error"
+	case level >= slog.LevelWarn:
+		return "warn"
+	case level >= slog.LevelInfo:
+		return "info"
+	case level >= slog.LevelDebug:
+		return "debug"
+	default:
+		return "trace" // mapped to debug by Datadog's status remapper
+	}
+}
+
+// datadogID converts a W3C trace or span ID to Datadog's decimal format, which is based on the
+// lower 64 bits of the ID.
+func datadogID(id string) string {
+	if len(id) > 16 {
+		id = id[len(id)-16:]
+	}
+	n, err := strconv.ParseUint(id, 16, 64)
+	if err != nil {
+		return id
+	}
+	return strconv.FormatUint(n, 10)
+}
+
+// gcpAttrReplacer maps attributes to the special fields of Cloud Logging's structured logging:
+// https://cloud.google.com/logging/docs/structured-logging#special-payload-fields
+func gcpAttrReplacer(groups []string, a slog.Attr) slog.Attr {
+	if a.Key == StackKey {
+		if stack, ok := a.Value.Any().(stackValue); ok {
+			// Error Reporting recognizes Go stack traces by their goroutine header.
+			var sb strings.Builder
+			sb.WriteString("goroutine 1 [running]:\n")
+			_ = stack.writeStandardFrames(&sb)
+			return slog.String("stack_trace", sb.String())
+		}
+	}
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.MessageKey:
+		a.Key = "message"
+	case slog.LevelKey:
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String("severity", gcpSeverity(level))
+		}
+	case slog.SourceKey:
+		if src, ok := a.Value.Any().(*slog.Source); ok {
+			return slog.Group("logging.googleapis.com/sourceLocation",
+				slog.String("file", src.File),
+				slog.String("line", strconv.Itoa(src.Line)),
+				slog.String("function", src.Function))
+		}
+	case TraceIDKey:
+		trace := a.Value.String()
+		if GCPProjectID != "" {
+			trace = fmt.Sprintf("projects/%s/traces/%s", GCPProjectID, trace)
+		}
+		return slog.String("logging.googleapis.com/trace", trace)
+	case SpanIDKey:
+		return slog.String("logging.googleapis.com/spanId", a.Value.String())
+	}
+	return a
+}
+
+func gcpSeverity(level slog.Level) string {
+	switch {
+	case level >= LevelFatal:
+		return "CRITICAL"
+	case level >= slog.LevelError:
+		return "ERROR"
+	case level >= slog.LevelWarn:
+		return "WARNING"
+	case level >= slog.LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG" // includes TRACE
+	}
+}
+
+// ecsAttrReplacer maps attributes to Elastic Common Schema fields:
+// https://www.elastic.co/guide/en/ecs/current/ecs-field-reference.html
+func ecsAttrReplacer(groups []string, a slog.Attr) slog.Attr {
+	switch a.Key {
+	case ErrKey:
+		if err, ok := a.Value.Any().(error); ok {
+			return inline(slog.String("error.type", errorKind(err)), slog.String("error.message", err.Error()))
+		}
+	case StackKey:
+		if stack, ok := a.Value.Any().(stackValue); ok {
+			return slog.String("error.stack_trace", stack.String())
+		}
+	}
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		a.Key = "@timestamp"
+	case slog.MessageKey:
+		a.Key = "message"
+	case slog.LevelKey:
+		if level, ok := a.Value.Any().(slog.Level); ok {
+			return slog.String("log.level", strings.ToLower(levelName(level)))
+		}
+	case TraceIDKey:
+		a.Key = "trace.id"
+	case SpanIDKey:
+		a.Key = "span.id"
+	}
+	return a
+}
+
+// inline returns a group without key, whose attributes are inlined by slog handlers. Collectors
+// treat dotted keys like nested objects, which avoids clashes between separately logged
+// attributes of the same object, e.g. an error and its stack.
+func inline(attrs ...slog.Attr) slog.Attr {
+	return slog.Attr{Value: slog.GroupValue(attrs...)}
+}
+
+// errorKind returns the type of the outermost error of a chain that is not a mere wrapper, which
+// is more telling than the type of wrappers, e.g. *fs.PathError instead of *fmt.wrapError. Of
+// wrappers of multiple errors, e.g. fmt.Errorf("%w: %w", a, b), the first error is followed.
+func errorKind(err error) string {
+	for {
+		next := unwrapWrapper(err)
+		if next == nil {
+			return fmt.Sprintf("%T", err)
+		}
+		err = next
+	}
+}
+
+// unwrapWrapper returns the first error wrapped by err if err is a mere wrapper, and nil otherwise.
+// Besides logutil's wrappers, a wrapper is an error of an unexported type without methods beyond
+// Error and Unwrap, whose message includes the messages of all wrapped errors, like the errors of
+// fmt.Errorf with %w. Exported types such as *fs.PathError are considered meaningful on their own.
+func unwrapWrapper(err error) error {
+	switch err.(type) {
+	case *scopedError, *severityError, loggedError:
+		return errors.Unwrap(err)
+	}
+	var wrapped []error
+	switch x := err.(type) {
+	case interface{ Unwrap() error }:
+		wrapped = []error{x.Unwrap()}
+	case interface{ Unwrap() []error }:
+		wrapped = x.Unwrap()
+	}
+	if len(wrapped) == 0 || wrapped[0] == nil || !isPlainErrorType(reflect.TypeOf(err)) {
+		return nil
+	}
+	msg := err.Error()
+	for _, next := range wrapped {
+		if !strings.Contains(msg, next.Error()) {
+			return nil
+		}
+	}
+	return wrapped[0]
+}
+
+func isPlainErrorType(t reflect.Type) bool {
+	if t.NumMethod() != 2 { // Error and Unwrap
+		return false
+	}
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Name() != "" && !token.IsExported(t.Name())
+}
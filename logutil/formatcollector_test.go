@@ -0,0 +1,157 @@
+package logutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHandlerTo_CollectorFormats(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   map[string]any
+		keys   []string
+	}{
+		{
+			format: FormatDatadog,
+			want: map[string]any{
+				"status":        "critical",
+				"message":       "message",
+				"error.kind":    "*fs.PathError",
+				"error.message": "request failed: open x: file does not exist",
+				"dd.trace_id":   "11803532876627986230",
+				"dd.span_id":    "13527612320720337851",
+			},
+			keys: []string{"timestamp", "error.stack"},
+		},
+		{
+			format: FormatGCP,
+			want: map[string]any{
+				"severity":                      "CRITICAL",
+				"message":                       "message",
+				"error":                         "request failed: open x: file does not exist",
+				"logging.googleapis.com/trace":  "4bf92f3577b34da6a3ce929d0e0e4736",
+				"logging.googleapis.com/spanId": "bbbbbbbbbbbbbbbb",
+			},
+			keys: []string{"time", "stack_trace", "logging.googleapis.com/sourceLocation"},
+		},
+		{
+			format: FormatECS,
+			want: map[string]any{
+				"log.level":     "fatal",
+				"message":       "message",
+				"ecs.version":   ecsVersion,
+				"error.type":    "*fs.PathError",
+				"error.message": "request failed: open x: file does not exist",
+				"trace.id":      "4bf92f3577b34da6a3ce929d0e0e4736",
+				"span.id":       "bbbbbbbbbbbbbbbb",
+			},
+			keys: []string{"@timestamp", "error.stack_trace"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			r := require.New(t)
+			a := assert.New(t)
+
+			buf := bytes.NewBuffer(nil)
+			h, err := NewHandlerTo(buf, tt.format, LevelTrace)
+			r.NoError(err)
+			testErr := NewError(&fs.PathError{Op: "open", Path: "x", Err: fs.ErrNotExist}, "request failed", Stack(0))
+			slog.New(h).Log(t.Context(), LevelFatal, "message", Err(testErr),
+				slog.String(TraceIDKey, "4bf92f3577b34da6a3ce929d0e0e4736"),
+				slog.String(SpanIDKey, "bbbbbbbbbbbbbbbb"))
+
+			var entry map[string]any
+			r.NoError(json.Unmarshal(buf.Bytes(), &entry))
+			for k, v := range tt.want {
+				a.Equal(v, entry[k], k)
+			}
+			for _, k := range tt.keys {
+				a.Contains(entry, k)
+			}
+			a.NotContains(entry, slog.LevelKey)
+			a.NotContains(entry, slog.MessageKey)
+		})
+	}
+}
+
+func TestNewHandlerTo_CollectorFormats_Group(t *testing.T) {
+	tests := []struct {
+		format Format
+		want   map[string]any
+		keys   []string
+	}{
+		{format: FormatDatadog, want: map[string]any{"error.kind": "*fs.PathError"}, keys: []string{"error.stack"}},
+		{format: FormatGCP, keys: []string{"stack_trace"}},
+		{format: FormatECS, want: map[string]any{"error.type": "*fs.PathError"}, keys: []string{"error.stack_trace"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			r := require.New(t)
+			a := assert.New(t)
+
+			buf := bytes.NewBuffer(nil)
+			h, err := NewHandlerTo(buf, tt.format, LevelTrace)
+			r.NoError(err)
+			testErr := NewError(&fs.PathError{Op: "open", Path: "x", Err: fs.ErrNotExist}, "request failed", Stack(0))
+			slog.New(h).WithGroup("req").Error("message", Err(testErr))
+
+			var entry map[string]any
+			r.NoError(json.Unmarshal(buf.Bytes(), &entry))
+			group, ok := entry["req"].(map[string]any)
+			r.True(ok)
+			for k, v := range tt.want {
+				a.Equal(v, group[k], k)
+			}
+			for _, k := range tt.keys {
+				a.Contains(group, k)
+			}
+			a.NotContains(group, StackKey)
+		})
+	}
+}
+
+func TestNewHandlerTo_Logfmt(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	buf := bytes.NewBuffer(nil)
+	h, err := NewHandlerTo(buf, FormatLogfmt, LevelTrace)
+	r.NoError(err)
+	slog.New(h).Log(t.Context(), LevelTrace, "hello world", slog.String("foo", "bar"), Stack(0))
+	a.Contains(buf.String(), `level=TRACE msg="hello world" foo=bar stack="goroutine:\n`)
+}
+
+type wrapperWithoutMessage struct{ err error }
+
+func (e *wrapperWithoutMessage) Error() string { return "failed" }
+func (e *wrapperWithoutMessage) Unwrap() error { return e.err }
+
+func Test_errorKind(t *testing.T) {
+	pathErr := &fs.PathError{Op: "open", Path: "config.yaml", Err: fs.ErrNotExist}
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "plain", err: errors.New("fail"), want: "*errors.errorString"},
+		{name: "wrapped", err: fmt.Errorf("load: %w", pathErr), want: "*fs.PathError"},
+		{name: "wrapped suffix", err: fmt.Errorf("%w (retrying)", pathErr), want: "*fs.PathError"},
+		{name: "wrapped twice", err: fmt.Errorf("load: %w: %w", pathErr, errors.New("fail")), want: "*fs.PathError"},
+		{name: "joined", err: errors.Join(pathErr, errors.New("fail")), want: "*fs.PathError"},
+		{name: "exported wrapper", err: pathErr, want: "*fs.PathError"},
+		{name: "own message", err: &wrapperWithoutMessage{err: pathErr}, want: "*logutil.wrapperWithoutMessage"},
+		{name: "scoped", err: Severity(NewError(pathErr, "load"), slog.LevelWarn), want: "*fs.PathError"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, errorKind(tt.err), tt.name)
+	}
+}
@@ -12,11 +12,17 @@ import (
 	"github.com/mattn/go-isatty"
 )
 
-func NewHandler(format Format, level slog.Level) (slog.Handler, error) {
-	return NewHandlerTo(os.Stderr, format, level)
+func NewHandler(format Format, level slog.Leveler, extractors ...ContextExtractor) (slog.Handler, error) {
+	return NewHandlerTo(os.Stderr, format, level, extractors...)
 }
 
-func NewHandlerTo(w io.Writer, format Format, level slog.Level) (slog.Handler, error) {
+// NewHandlerTo creates a handler that writes records in the given format to w. Records that are
+// logged with a context get the attributes of extractors, e.g. ContextAttrs.
+func NewHandlerTo(w io.Writer, format Format, level slog.Leveler, extractors ...ContextExtractor) (slog.Handler, error) {
+	return newHandlerTo(w, format, level, ErrorChain, extractors...)
+}
+
+func newHandlerTo(w io.Writer, format Format, level slog.Leveler, errorChain bool, extractors ...ContextExtractor) (slog.Handler, error) {
 	var handler slog.Handler
 	switch format {
 	case FormatText:
@@ -35,15 +41,40 @@ func NewHandlerTo(w io.Writer, format Format, level slog.Level) (slog.Handler, e
 			Level:       level,
 			ReplaceAttr: LevelAttrReplacer,
 		})
+	case FormatLogfmt:
+		handler = slog.NewTextHandler(w, &slog.HandlerOptions{
+			Level:       level,
+			ReplaceAttr: logfmtAttrReplacer,
+		})
+	case FormatDatadog:
+		handler = slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:       level,
+			ReplaceAttr: datadogAttrReplacer,
+		})
+	case FormatGCP:
+		handler = slog.NewJSONHandler(w, &slog.HandlerOptions{
+			AddSource:   true,
+			Level:       level,
+			ReplaceAttr: gcpAttrReplacer,
+		})
+	case FormatECS:
+		handler = slog.NewJSONHandler(w, &slog.HandlerOptions{
+			Level:       level,
+			ReplaceAttr: ecsAttrReplacer,
+		}).WithAttrs([]slog.Attr{slog.String("ecs.version", ecsVersion)})
 	default:
 		return nil, fmt.Errorf("unsupported log format: %s", format)
 	}
-	return &scopedErrorHandler{next: handler}, nil
+	handler = &redactHandler{next: handler}
+	if len(extractors) > 0 {
+		handler = &contextHandler{next: handler, extractors: extractors}
+	}
+	return &scopedErrorHandler{next: handler, chain: errorChain}, nil
 }
 
 // MustNewHandler forwards to NewHandler and panics when it fails.
 // It is still used by AVAS, can eventually go away for mainutil.
-func MustNewHandler(format Format, level slog.Level) slog.Handler {
+func MustNewHandler(format Format, level slog.Leveler) slog.Handler {
 	h, err := NewHandler(format, level)
 	if err != nil {
 		panic(err)
@@ -104,6 +135,7 @@ func (w *termOutputWrapper) attrReplacer(groups []string, a slog.Attr) slog.Attr
 type scopedErrorHandler struct {
 	next     slog.Handler
 	errAttrs []slog.Attr
+	chain    bool // see ErrorChain
 }
 
 func (d *scopedErrorHandler) Enabled(ctx context.Context, level slog.Level) bool {
@@ -111,41 +143,85 @@ func (d *scopedErrorHandler) Enabled(ctx context.Context, level slog.Level) bool
 }
 
 func (d *scopedErrorHandler) Handle(ctx context.Context, record slog.Record) error {
-	errAttrs := d.errAttrs
+	hasErr := false
 	record.Attrs(func(attr slog.Attr) bool {
-		if err := extractErr(attr); err != nil {
-			errAttrs = slices.Clone(errAttrs)
-			errAttrs = append(errAttrs, Destructure(err)...)
-			return false // stop
-		}
-		return true // next key
+		hasErr = extractErr(attr) != nil
+		return !hasErr // stop at the first error
 	})
-	if len(errAttrs) > 0 {
-		record = record.Clone()
-		record.AddAttrs(errAttrs...)
+	if !hasErr {
+		if len(d.errAttrs) > 0 {
+			record = record.Clone()
+			record.AddAttrs(d.errAttrs...)
+		}
+		return d.next.Handle(ctx, record)
 	}
-	return d.next.Handle(ctx, record)
+
+	// The error is replaced by its loggedError, which needs a new record.
+	attrs := make([]slog.Attr, 0, record.NumAttrs())
+	record.Attrs(func(attr slog.Attr) bool {
+		attrs = append(attrs, attr)
+		return true
+	})
+	attrs, errAttrs := destructureErr(attrs, d.chain)
+	out := slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+	out.AddAttrs(attrs...)
+	out.AddAttrs(d.errAttrs...)
+	out.AddAttrs(errAttrs...)
+	return d.next.Handle(ctx, out)
 }
 
 func (d *scopedErrorHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
 	// WithAttrs typically renders all existing attributes as string prefix. Handle will only
 	// receive the latest transient set of attributes. Prepare by destructuring the error early.
-	errAttrs := d.errAttrs
-	for _, attr := range attrs {
-		if err := extractErr(attr); err != nil {
-			errAttrs = slices.Clone(errAttrs)
-			errAttrs = append(errAttrs, Destructure(err)...)
-			break
-		}
+	attrs, errAttrs := destructureErr(attrs, d.chain)
+	if errAttrs != nil {
+		errAttrs = append(slices.Clone(d.errAttrs), errAttrs...)
+	} else {
+		errAttrs = d.errAttrs
 	}
 	return &scopedErrorHandler{
 		next:     d.next.WithAttrs(attrs),
 		errAttrs: errAttrs,
+		chain:    d.chain,
 	}
 }
 
 func (d *scopedErrorHandler) WithGroup(name string) slog.Handler {
-	return &scopedErrorHandler{next: d.next.WithGroup(name)}
+	return &scopedErrorHandler{next: d.next.WithGroup(name), chain: d.chain}
+}
+
+// destructureErr replaces the first error under ErrKey by its loggedError, and returns the error's
+// attributes, including its chain if chain is set. The error's stack is added, unless the record
+// has a stack already, e.g. of a panic. errAttrs is nil if there is no error.
+func destructureErr(attrs []slog.Attr, chain bool) (_ []slog.Attr, errAttrs []slog.Attr) {
+	for i, attr := range attrs {
+		if err := extractErr(attr); err != nil {
+			errAttr := slog.Any(ErrKey, newLoggedError(err))
+			if attr.Value.Kind() == slog.KindLogValuer {
+				errAttr = ErrColor(errAttr) // retain the color of Err
+			}
+			attrs = slices.Clone(attrs)
+			attrs[i] = errAttr
+			errAttrs = append([]slog.Attr{}, Destructure(err)...)
+			if stack := errorStack(err); stack != nil && !hasStack(attrs) {
+				errAttrs = append(errAttrs, slog.Any(StackKey, stackValue{pcs: stack}))
+			}
+			if chain {
+				links := ErrorLinks(err)
+				errAttrs = append(errAttrs,
+					slog.Any(ErrKey+".chain", links),
+					slog.String(ErrKey+".fingerprint", fingerprint(links)))
+			}
+			return attrs, errAttrs
+		}
+	}
+	return attrs, nil
+}
+
+func hasStack(attrs []slog.Attr) bool {
+	return slices.ContainsFunc(attrs, func(attr slog.Attr) bool {
+		return attr.Key == StackKey
+	})
 }
 
 func extractErr(attr slog.Attr) error {
@@ -2,9 +2,15 @@ package logutil
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"log/slog"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -29,4 +35,91 @@ func TestNewHandler_ScopedError(t *testing.T) {
 	a.EqualValues("message", slogMsg[slog.MessageKey])
 	a.EqualValues("test error", slogMsg[ErrKey])
 	a.EqualValues("bar", slogMsg["foo"])
+
+	// the wrapper's own text is retained, even if it resembles the attributes
+	buf.Reset()
+	log.Info("message", Err(fmt.Errorf(`retry with foo="bar": %w`, testErr)))
+	r.NoError(json.Unmarshal(buf.Bytes(), &slogMsg))
+	a.EqualValues(`retry with foo="bar": test error`, slogMsg[ErrKey])
+}
+
+func TestNewHandler_JoinedErrors(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	scope := NewScope("kafka", slog.String("topic", "events"))
+	testErr := NewError(errors.Join(
+		scope.New("commit failed", slog.Int("partition", 3)),
+		fmt.Errorf("close: %w", NewError(nil, "flush failed", slog.Int("pending", 7))),
+	), "shutdown failed", slog.String("consumer", "c-1"))
+
+	buf := bytes.NewBuffer(nil)
+	h, err := NewHandlerTo(buf, FormatJSON, LevelTrace)
+	r.NoError(err)
+	log := slog.New(h)
+	for range 2 {
+		buf.Reset()
+		log.Info("message", Err(testErr))
+
+		var entry map[string]any
+		r.NoError(json.Unmarshal(buf.Bytes(), &entry))
+		a.Equal("shutdown failed: commit failed\nclose: flush failed", entry[ErrKey])
+		a.Equal("c-1", entry["consumer"])
+		a.Equal(map[string]any{
+			"0": map[string]any{
+				"message": "commit failed",
+				"kafka":   map[string]any{"topic": "events", "partition": float64(3)},
+			},
+			"1": map[string]any{
+				"message": "close: flush failed",
+				"pending": float64(7),
+			},
+		}, entry[ErrKey+".causes"])
+	}
+}
+
+func TestNewHandler_ErrorStacks(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	ErrorStacks = true
+	t.Cleanup(func() { ErrorStacks = false })
+
+	inner := NewScope("db").New("no rows")
+	ErrorStacks = false
+	outer := NewError(inner, "lookup failed")
+	a.Equal("lookup failed: no rows", outer.Error())
+
+	topFunction := func(stack []uintptr) string {
+		frame, _ := runtime.CallersFrames(stack).Next()
+		return frame.Function
+	}
+	stack := errorStack(outer)
+	r.NotEmpty(stack)
+	a.Equal("github.com/authenticvision/util-go/logutil.TestNewHandler_ErrorStacks", topFunction(stack))
+
+	buf := bytes.NewBuffer(nil)
+	h, err := NewHandlerTo(buf, FormatLogfmt, slog.LevelInfo)
+	r.NoError(err)
+	log := slog.New(h)
+	log.Info("message", Err(outer))
+	a.Equal(1, strings.Count(buf.String(), "stack="))
+	a.Contains(buf.String(), "logutil.TestNewHandler_ErrorStacks(")
+
+	buf.Reset()
+	log.Info("message", Err(outer), Stack(0))
+	a.Equal(1, strings.Count(buf.String(), "stack="), "the record's stack takes precedence")
+}
+
+func TestScopedErrorHandler_WithoutError(t *testing.T) {
+	a := assert.New(t)
+
+	// records without an error are passed on as they are
+	h := &scopedErrorHandler{next: slog.DiscardHandler}
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "message", 0)
+	record.AddAttrs(slog.String("foo", "bar"), slog.Int("n", 1))
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = h.Handle(context.Background(), record)
+	})
+	a.Zero(allocs)
 }
@@ -0,0 +1,204 @@
+package logutil
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/BooleanCat/go-functional/v2/it/op"
+	"github.com/spf13/pflag"
+)
+
+var _ pflag.Value = op.Ref(LevelSpec{})
+
+// LevelSpec is a log level specification like "INFO,kafka=DEBUG,grpc=WARN". It consists of an
+// optional default level for ProcessLevel, and levels for scopes by their group name.
+type LevelSpec struct {
+	Default *Level
+	Scopes  map[string]Level
+}
+
+func (s *LevelSpec) UnmarshalText(text []byte) error {
+	spec := LevelSpec{Scopes: make(map[string]Level)}
+	for entry := range strings.SplitSeq(string(text), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		scope, levelText, isScope := strings.Cut(entry, "=")
+		var level Level
+		if !isScope {
+			levelText = entry
+		}
+		if err := level.UnmarshalText([]byte(strings.TrimSpace(levelText))); err != nil {
+			return fmt.Errorf("invalid level in log level spec entry %q: %w", entry, err)
+		}
+		if !isScope {
+			if spec.Default != nil {
+				return fmt.Errorf("duplicate default level in log level spec: %q", text)
+			}
+			spec.Default = &level
+		} else if scope = strings.TrimSpace(scope); scope == "" {
+			return fmt.Errorf("missing scope in log level spec entry %q", entry)
+		} else {
+			spec.Scopes[scope] = level
+		}
+	}
+	*s = spec
+	return nil
+}
+
+func (s *LevelSpec) String() string {
+	var entries []string
+	if s.Default != nil {
+		entries = append(entries, s.Default.String())
+	}
+	for _, scope := range slices.Sorted(maps.Keys(s.Scopes)) {
+		level := s.Scopes[scope]
+		entries = append(entries, scope+"="+level.String())
+	}
+	return strings.Join(entries, ",")
+}
+
+func (s *LevelSpec) Set(text string) error {
+	return s.UnmarshalText([]byte(text))
+}
+
+func (s *LevelSpec) Type() string {
+	return "levels"
+}
+
+// scopeLevels holds the levels of LevelSpec.Scopes. Loggers look it up when logging, so that it
+// can be changed at runtime.
+var scopeLevels atomic.Pointer[map[string]slog.Level]
+
+// CurrentLevelSpec returns ProcessLevel and the current per-scope levels.
+func CurrentLevelSpec() LevelSpec {
+	level := Level(ProcessLevel.Level())
+	spec := LevelSpec{Default: &level, Scopes: make(map[string]Level)}
+	if levels := scopeLevels.Load(); levels != nil {
+		for scope, level := range *levels {
+			spec.Scopes[scope] = Level(level)
+		}
+	}
+	return spec
+}
+
+// SetLevelSpec replaces the per-scope levels and, if the spec has a default level, changes
+// ProcessLevel. Changes are logged to log.
+func SetLevelSpec(log *slog.Logger, spec LevelSpec) {
+	if spec.Default != nil {
+		SetProcessLevel(log, slog.Level(*spec.Default))
+	}
+	old := CurrentLevelSpec()
+	setScopeLevels(spec.Scopes)
+	if !maps.Equal(old.Scopes, spec.Scopes) && (len(old.Scopes) != 0 || len(spec.Scopes) != 0) {
+		oldScopes, newScopes := LevelSpec{Scopes: old.Scopes}, LevelSpec{Scopes: spec.Scopes}
+		// logged as warning like SetProcessLevel, so that the change is visible at a WARN default
+		log.Log(context.Background(), slog.LevelWarn, "scope log levels changed",
+			slog.String("from", oldScopes.String()),
+			slog.String("to", newScopes.String()))
+	}
+}
+
+func setScopeLevels(scopes map[string]Level) {
+	scopeLevels.Store(scopeLevelMap(scopes))
+}
+
+func scopeLevelMap(scopes map[string]Level) *map[string]slog.Level {
+	levels := make(map[string]slog.Level, len(scopes))
+	for scope, level := range scopes {
+		levels[scope] = slog.Level(level)
+	}
+	return &levels
+}
+
+// handlerLevels are the default and per-scope levels of a handler.
+type handlerLevels struct {
+	level  *slog.LevelVar
+	scopes *atomic.Pointer[map[string]slog.Level]
+}
+
+// processLevels are the levels of handlers installed through Config.InstallForProcess, and of
+// any handler that does not carry its own levels.
+var processLevels = handlerLevels{level: ProcessLevel, scopes: &scopeLevels}
+
+// levelsOf returns the levels carried by h, see levelsHandler, or processLevels.
+func levelsOf(h slog.Handler) handlerLevels {
+	if x, ok := h.(interface{ levels() handlerLevels }); ok {
+		return x.levels()
+	}
+	return processLevels
+}
+
+// levelsHandler carries the levels of a handler created through Config.NewHandler, so that
+// scopeLevelHandler applies that handler's scope levels instead of the process's.
+type levelsHandler struct {
+	next slog.Handler
+	lv   handlerLevels
+}
+
+func (h *levelsHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *levelsHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.next.Handle(ctx, record)
+}
+
+func (h *levelsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &levelsHandler{next: h.next.WithAttrs(attrs), lv: h.lv}
+}
+
+func (h *levelsHandler) WithGroup(name string) slog.Handler {
+	return &levelsHandler{next: h.next.WithGroup(name), lv: h.lv}
+}
+
+func (h *levelsHandler) levels() handlerLevels {
+	return h.lv
+}
+
+// scopeLevelHandler applies the level of a scope, if one is configured, in place of the level of
+// the next handler. This permits enabling debug logs for a single scope.
+type scopeLevelHandler struct {
+	next  slog.Handler
+	scope string
+}
+
+func (h *scopeLevelHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if scopeLevel, ok := h.level(); ok {
+		return level >= scopeLevel
+	}
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *scopeLevelHandler) Handle(ctx context.Context, record slog.Record) error {
+	if _, ok := h.level(); ok {
+		ctx = withForcedEnabled(ctx)
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *scopeLevelHandler) level() (slog.Level, bool) {
+	if levels := levelsOf(h.next).scopes.Load(); levels != nil {
+		level, ok := (*levels)[h.scope]
+		return level, ok
+	}
+	return 0, false
+}
+
+func (h *scopeLevelHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &scopeLevelHandler{next: h.next.WithAttrs(attrs), scope: h.scope}
+}
+
+func (h *scopeLevelHandler) WithGroup(name string) slog.Handler {
+	return &scopeLevelHandler{next: h.next.WithGroup(name), scope: h.scope}
+}
+
+func (h *scopeLevelHandler) levels() handlerLevels {
+	return levelsOf(h.next)
+}
@@ -0,0 +1,92 @@
+package logutil
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLevelSpec_UnmarshalText(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{text: "", want: ""},
+		{text: "info", want: "INFO"},
+		{text: "INFO,kafka=DEBUG,grpc=WARN", want: "INFO,grpc=WARN,kafka=DEBUG"},
+		{text: " kafka = trace ", want: "kafka=TRACE"},
+		{text: "INFO,DEBUG", wantErr: true},
+		{text: "=DEBUG", wantErr: true},
+		{text: "kafka=LOUD", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			var spec LevelSpec
+			err := spec.UnmarshalText([]byte(tt.text))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, spec.String())
+		})
+	}
+}
+
+func TestScope_Log_Level(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	prev := CurrentLevelSpec()
+	t.Cleanup(func() {
+		ProcessLevel.Set(slog.Level(*prev.Default))
+		setScopeLevels(prev.Scopes)
+	})
+	ProcessLevel.Set(slog.LevelInfo)
+
+	buf := bytes.NewBuffer(nil)
+	h, err := NewHandlerTo(buf, FormatJSON, ProcessLevel)
+	r.NoError(err)
+	root := slog.New(h)
+	kafkaLog := NewScope("kafka").Log(root)
+	grpcLog := NewScope("grpc").Log(root)
+
+	kafkaLog.Debug("kafka hidden")
+	a.Empty(buf.String())
+
+	var spec LevelSpec
+	r.NoError(spec.UnmarshalText([]byte("kafka=DEBUG,grpc=ERROR")))
+	SetLevelSpec(slog.New(slog.DiscardHandler), spec)
+
+	kafkaLog.Debug("kafka visible")
+	a.Contains(buf.String(), "kafka visible")
+	grpcLog.Warn("grpc hidden")
+	root.Debug("root hidden")
+	a.NotContains(buf.String(), "hidden")
+}
+
+func TestSetLevelSpec_LogsChanges(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	prev := CurrentLevelSpec()
+	t.Cleanup(func() {
+		ProcessLevel.Set(slog.Level(*prev.Default))
+		setScopeLevels(prev.Scopes)
+	})
+	ProcessLevel.Set(slog.LevelWarn)
+	setScopeLevels(nil)
+
+	buf := bytes.NewBuffer(nil)
+	h, err := NewHandlerTo(buf, FormatJSON, ProcessLevel)
+	r.NoError(err)
+	var spec LevelSpec
+	r.NoError(spec.UnmarshalText([]byte("kafka=DEBUG")))
+	SetLevelSpec(slog.New(h), spec)
+	a.Contains(buf.String(), `"msg":"scope log levels changed"`)
+	a.Contains(buf.String(), `"to":"kafka=DEBUG"`)
+}
@@ -0,0 +1,91 @@
+package logutil
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// ProcessLevel is the minimum level of handlers created through Config. It is initialized from
+// Config.Level and can be changed at runtime via SetProcessLevel, see also Config.WatchLevel.
+var ProcessLevel = new(slog.LevelVar)
+
+// LevelFilePollInterval is the interval at which Config.LevelFile is checked for changes.
+var LevelFilePollInterval = 5 * time.Second
+
+// levelSteps are the levels that signals step through, from most to least verbose.
+var levelSteps = []slog.Level{LevelTrace, slog.LevelDebug, slog.LevelInfo, slog.LevelWarn, slog.LevelError, LevelFatal}
+
+// SetProcessLevel changes ProcessLevel and logs the change to log.
+func SetProcessLevel(log *slog.Logger, level slog.Level) {
+	old := ProcessLevel.Level()
+	ProcessLevel.Set(level)
+	if old != level {
+		// logged as warning, so that the change is visible unless only errors are logged
+		log.Log(context.Background(), slog.LevelWarn, "log level changed",
+			slog.String("from", levelName(old)),
+			slog.String("to", levelName(level)))
+	}
+}
+
+func levelName(level slog.Level) string {
+	l := Level(level)
+	return l.String()
+}
+
+// stepLevel returns the next level of levelSteps towards more (delta < 0) or less verbose logging.
+func stepLevel(level slog.Level, delta int) slog.Level {
+	i := 0
+	for i < len(levelSteps)-1 && levelSteps[i] < level {
+		i++
+	}
+	i = min(max(i+delta, 0), len(levelSteps)-1)
+	return levelSteps[i]
+}
+
+// WatchLevel changes ProcessLevel at runtime until ctx is done:
+//
+//   - SIGUSR1 makes logging more verbose by one level, e.g. from INFO to DEBUG
+//   - SIGUSR2 makes logging less verbose by one level
+//   - when LevelFile is set, its content is applied as LevelSpec whenever it changes
+//
+// Changes are logged to log. Signals are not supported on Windows.
+func (c Config) WatchLevel(ctx context.Context, log *slog.Logger) {
+	watchLevelSignals(ctx, log.With(slog.String("via", "signal")))
+	if c.LevelFile != "" {
+		go watchLevelFile(ctx, log.With(slog.String("via", "file")), c.LevelFile)
+	}
+}
+
+func watchLevelFile(ctx context.Context, log *slog.Logger, name string) {
+	ticker := time.NewTicker(LevelFilePollInterval)
+	defer ticker.Stop()
+	var last []byte
+	for {
+		content, err := os.ReadFile(name)
+		if errors.Is(err, fs.ErrNotExist) {
+			// keep the current level, e.g. while a mounted ConfigMap is absent
+			last = nil
+		} else if err != nil {
+			log.Warn("failed to read log level file", slog.String("file", name), Err(err))
+		} else if content = bytes.TrimSpace(content); !bytes.Equal(content, last) {
+			last = content
+			var spec LevelSpec
+			if err := spec.UnmarshalText(content); err != nil {
+				log.Warn("invalid log level file", slog.String("file", name), Err(err))
+			} else {
+				SetLevelSpec(log, spec)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
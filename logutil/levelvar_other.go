@@ -0,0 +1,10 @@
+//go:build !unix
+
+package logutil
+
+import (
+	"context"
+	"log/slog"
+)
+
+func watchLevelSignals(context.Context, *slog.Logger) {}
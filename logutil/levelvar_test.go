@@ -0,0 +1,52 @@
+package logutil
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_stepLevel(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		delta int
+		want  slog.Level
+	}{
+		{level: slog.LevelInfo, delta: -1, want: slog.LevelDebug},
+		{level: slog.LevelDebug, delta: -1, want: LevelTrace},
+		{level: LevelTrace, delta: -1, want: LevelTrace},
+		{level: slog.LevelInfo, delta: 1, want: slog.LevelWarn},
+		{level: slog.LevelError, delta: 1, want: LevelFatal},
+		{level: LevelFatal, delta: 1, want: LevelFatal},
+		{level: LevelFatal, delta: -1, want: slog.LevelError},
+		{level: slog.LevelInfo + 1, delta: -1, want: slog.LevelInfo},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, stepLevel(tt.level, tt.delta), "%s%+d", tt.level, tt.delta)
+	}
+}
+
+func TestConfig_WatchLevel_File(t *testing.T) {
+	r := require.New(t)
+
+	prevLevel, prevInterval := ProcessLevel.Level(), LevelFilePollInterval
+	t.Cleanup(func() {
+		ProcessLevel.Set(prevLevel)
+		LevelFilePollInterval = prevInterval
+	})
+	LevelFilePollInterval = time.Millisecond
+	ProcessLevel.Set(slog.LevelInfo)
+
+	name := filepath.Join(t.TempDir(), "level")
+	r.NoError(os.WriteFile(name, []byte("trace\n"), 0o644))
+	Config{LevelFile: name}.WatchLevel(t.Context(), slog.New(slog.DiscardHandler))
+	r.Eventually(func() bool { return ProcessLevel.Level() == LevelTrace }, time.Second, time.Millisecond)
+
+	r.NoError(os.WriteFile(name, []byte("WARN"), 0o644))
+	r.Eventually(func() bool { return ProcessLevel.Level() == slog.LevelWarn }, time.Second, time.Millisecond)
+}
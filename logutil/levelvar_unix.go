@@ -0,0 +1,31 @@
+//go:build unix
+
+package logutil
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+func watchLevelSignals(ctx context.Context, log *slog.Logger) {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		defer signal.Stop(signals)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case sig := <-signals:
+				delta := 1
+				if sig == syscall.SIGUSR1 {
+					delta = -1
+				}
+				SetProcessLevel(log, stepLevel(ProcessLevel.Level(), delta))
+			}
+		}
+	}()
+}
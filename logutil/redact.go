@@ -0,0 +1,145 @@
+package logutil
+
+import (
+	"context"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Redacted replaces redacted values in logs.
+const Redacted = "[REDACTED]"
+
+// RedactKeys are attribute keys whose values are replaced by Redacted in handlers created through
+// NewHandlerTo. Keys are matched case-insensitively against an attribute's key and its path of
+// groups, e.g. "usr.email".
+var RedactKeys = []string{
+	"usr.email",
+	"authorization",
+	"cookie",
+	"set-cookie",
+}
+
+// RedactPatterns are matched against attribute keys like RedactKeys, except that matching
+// attributes in any group are redacted.
+var RedactPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)passw(or)?d|secret|token|api[-_]?key|credential|signature`),
+}
+
+// RedactKey reports whether values of the attribute with the given key, or dotted path of groups
+// and key, are redacted. Use it for key-value data that is logged as a single attribute, such as
+// query strings.
+func RedactKey(path string) bool {
+	key := path[strings.LastIndexByte(path, '.')+1:]
+	for _, k := range RedactKeys {
+		if strings.EqualFold(k, path) || strings.EqualFold(k, key) {
+			return true
+		}
+	}
+	for _, p := range RedactPatterns {
+		if p.MatchString(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// Secret is a string that is logged as Redacted, e.g. in text and JSON logs, in errors of
+// NewError and in JSON attributes. Its value is available via Reveal or a string conversion.
+type Secret string
+
+func (s Secret) Reveal() string {
+	return string(s)
+}
+
+func (Secret) String() string {
+	return Redacted
+}
+
+func (Secret) GoString() string {
+	return Redacted
+}
+
+func (Secret) LogValue() slog.Value {
+	return slog.StringValue(Redacted)
+}
+
+func (Secret) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + Redacted + `"`), nil
+}
+
+// redactHandler replaces values of attributes that match RedactKeys or RedactPatterns.
+type redactHandler struct {
+	next   slog.Handler
+	groups []string
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, record slog.Record) error {
+	var redacted []slog.Attr
+	changed := false
+	record.Attrs(func(attr slog.Attr) bool {
+		attr, ok := redactAttr(h.groups, attr)
+		changed = changed || ok
+		redacted = append(redacted, attr)
+		return true
+	})
+	if changed {
+		record = slog.NewRecord(record.Time, record.Level, record.Message, record.PC)
+		record.AddAttrs(redacted...)
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	redacted := make([]slog.Attr, len(attrs))
+	for i, attr := range attrs {
+		redacted[i], _ = redactAttr(h.groups, attr)
+	}
+	return &redactHandler{next: h.next.WithAttrs(redacted), groups: h.groups}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name), groups: append(slices.Clip(h.groups), name)}
+}
+
+// redactAttr returns attr with redacted values and whether anything was redacted. Unchanged
+// attributes are returned as-is, e.g. to retain tint's color information.
+func redactAttr(groups []string, attr slog.Attr) (slog.Attr, bool) {
+	path := attr.Key
+	if len(groups) > 0 {
+		path = strings.Join(groups, ".") + "." + attr.Key
+	}
+	if attr.Key != "" && RedactKey(path) {
+		return slog.String(attr.Key, Redacted), true
+	}
+
+	value := attr.Value
+	if value.Kind() == slog.KindLogValuer {
+		value = value.Resolve()
+	}
+	if value.Kind() != slog.KindGroup {
+		return attr, false
+	}
+	if attr.Key != "" {
+		groups = append(slices.Clip(groups), attr.Key)
+	}
+	members := value.Group()
+	var redacted []slog.Attr
+	for i, member := range members {
+		if member, ok := redactAttr(groups, member); ok {
+			if redacted == nil {
+				redacted = slices.Clone(members)
+			}
+			redacted[i] = member
+		}
+	}
+	if redacted == nil {
+		return attr, false
+	}
+	return slog.Attr{Key: attr.Key, Value: slog.GroupValue(redacted...)}, true
+}
@@ -0,0 +1,79 @@
+package logutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHandler_Redact(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	buf := bytes.NewBuffer(nil)
+	h, err := NewHandlerTo(buf, FormatJSON, slog.LevelInfo)
+	r.NoError(err)
+	log := slog.New(h).With(slog.String("api_key", "k-1"), User(UserValue{ID: "u-1", Email: "a@example.com"}))
+	testErr := NewError(nil, "login failed", slog.String("Password", "hunter2"), slog.String("name", "alice"))
+	log.WithGroup("req").Info("message",
+		slog.Group("headers", slog.String("Authorization", "Bearer x"), slog.String("Accept", "*/*")),
+		JSON("body", json.RawMessage(`{"refresh_token":"t-1","scope":"all"}`)),
+		slog.Any("secret_value", Secret("s-1")),
+		slog.Any("user_secret", "s-2"),
+		Err(testErr),
+	)
+
+	a.NotContains(buf.String(), "k-1")
+	a.NotContains(buf.String(), "a@example.com")
+	a.NotContains(buf.String(), "hunter2")
+	a.NotContains(buf.String(), "Bearer x")
+	a.NotContains(buf.String(), "t-1")
+	a.NotContains(buf.String(), "s-1")
+	a.NotContains(buf.String(), "s-2")
+
+	var entry map[string]any
+	r.NoError(json.Unmarshal(buf.Bytes(), &entry))
+	a.Equal(Redacted, entry["api_key"])
+	a.Equal(map[string]any{"id": "u-1", "email": Redacted}, entry[UserKey])
+	req := entry["req"].(map[string]any)
+	a.Equal(map[string]any{"Authorization": Redacted, "Accept": "*/*"}, req["headers"])
+	a.Equal(map[string]any{"refresh_token": Redacted, "scope": "all"}, req["body"])
+	a.Equal("alice", req["name"])
+	a.Equal(Redacted, req["Password"])
+}
+
+func TestSecret(t *testing.T) {
+	a := assert.New(t)
+
+	s := Secret("hunter2")
+	a.Equal("hunter2", s.Reveal())
+	a.Equal("hunter2", string(s))
+	a.Equal(Redacted, fmt.Sprint(s))
+	a.Equal(`[REDACTED] "[REDACTED]"`, fmt.Sprintf("%#v %q", s, s))
+
+	j, err := json.Marshal(struct{ S Secret }{S: s})
+	a.NoError(err)
+	a.JSONEq(`{"S":"[REDACTED]"}`, string(j))
+
+	err = NewError(nil, "failed", slog.Any("value", s), slog.String("token", "t-1"))
+	a.Equal(`failed with value="[REDACTED]" token="[REDACTED]"`, err.Error())
+}
+
+func TestScopedError_Redact(t *testing.T) {
+	a := assert.New(t)
+
+	err := NewError(nil, "failed",
+		slog.Group("db", slog.String("password", "hunter2"), slog.String("host", "h-1")),
+		JSON("body", json.RawMessage(`{"user":{"api_key":"k-1","name":"alice"}}`)),
+		JSON("query", json.RawMessage(`{"q":"x"}`)))
+	a.Equal(`failed with db="[password=[REDACTED] host=h-1]" `+
+		`body={"user":{"api_key":"[REDACTED]","name":"alice"}} query={"q":"x"}`, err.Error())
+
+	err = NewScope("usr").New("failed", slog.String("email", "a@example.com"))
+	a.Equal(`failed with email="[REDACTED]"`, err.Error())
+}
@@ -0,0 +1,117 @@
+package logutil
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// SamplingOptions configures NewSamplingHandler. Records are counted per level, message, and
+// call site within each interval. The zero value disables sampling.
+type SamplingOptions struct {
+	First      int           `usage:"number of identical records per interval to log before sampling, 0 disables sampling"`
+	Thereafter int           `usage:"log every nth record after the first ones, 0 suppresses all"`
+	Interval   time.Duration `usage:"interval after which sampling restarts and suppressed records are summarized"`
+}
+
+// NewSamplingHandler wraps next to rate-limit identical records, e.g. errors caused by a flapping
+// dependency. Per interval, the first opts.First records of each level, message, and call site
+// are logged, then every opts.Thereafter record. For each key with suppressed records, a summary
+// record is logged to next at the end of the interval. The summary has no attributes or groups of
+// loggers, because suppressed records of the same key may come from loggers with different ones.
+func NewSamplingHandler(next slog.Handler, opts SamplingOptions) slog.Handler {
+	if opts.First <= 0 {
+		return next
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = time.Second
+	}
+	return &samplingHandler{next: next, s: &sampler{base: next, opts: opts, counters: make(map[sampleKey]*sampleCounter)}}
+}
+
+type sampleKey struct {
+	level slog.Level
+	msg   string
+	pc    uintptr
+}
+
+type sampleCounter struct {
+	count      int
+	suppressed int
+}
+
+// sampler is shared between a samplingHandler and its derived handlers.
+type sampler struct {
+	base     slog.Handler // without attributes and groups of derived handlers, for summaries
+	opts     SamplingOptions
+	mu       sync.Mutex
+	counters map[sampleKey]*sampleCounter // reset each interval, which bounds memory use
+}
+
+// sample counts a record and reports whether it should be logged.
+func (s *sampler) sample(record slog.Record) bool {
+	key := sampleKey{level: record.Level, msg: record.Message, pc: record.PC}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.counters) == 0 {
+		time.AfterFunc(s.opts.Interval, s.summarize)
+	}
+	c, ok := s.counters[key]
+	if !ok {
+		c = &sampleCounter{}
+		s.counters[key] = c
+	}
+	c.count++
+	if c.count <= s.opts.First {
+		return true
+	} else if n := s.opts.Thereafter; n > 0 && (c.count-s.opts.First)%n == 0 {
+		return true
+	}
+	c.suppressed++
+	return false
+}
+
+// summarize ends the current interval and logs a summary per key with suppressed records.
+func (s *sampler) summarize() {
+	s.mu.Lock()
+	counters := s.counters
+	s.counters = make(map[sampleKey]*sampleCounter, len(counters))
+	s.mu.Unlock()
+
+	for key, c := range counters {
+		if c.suppressed == 0 {
+			continue
+		}
+		r := slog.NewRecord(time.Now(), key.level, "suppressed log records", key.pc)
+		r.AddAttrs(
+			slog.String("suppressed_msg", key.msg),
+			slog.Int("suppressed", c.suppressed),
+			slog.Duration("interval", s.opts.Interval))
+		_ = s.base.Handle(context.Background(), r)
+	}
+}
+
+type samplingHandler struct {
+	next slog.Handler
+	s    *sampler
+}
+
+func (h *samplingHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if !h.s.sample(record) {
+		return nil
+	}
+	return h.next.Handle(ctx, record)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{next: h.next.WithAttrs(attrs), s: h.s}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{next: h.next.WithGroup(name), s: h.s}
+}
@@ -0,0 +1,60 @@
+package logutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSamplingHandler(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	out := bytes.NewBuffer(nil)
+	h, err := NewHandlerTo(out, FormatJSON, slog.LevelInfo)
+	r.NoError(err)
+	sampling := NewSamplingHandler(h, SamplingOptions{First: 2, Thereafter: 3, Interval: time.Hour})
+	log := slog.New(sampling).With(slog.String("dependency", "db"))
+
+	for i := range 10 {
+		// records of loggers with different attributes and groups share a key
+		log := log.With(slog.Int("attempt", i)).WithGroup("retry")
+		log.Error("query failed", Err(NewError(errors.New("timeout"), "query", slog.String("table", "t"))))
+	}
+	log.Warn("other message")
+
+	var entries []map[string]any
+	for line := range strings.Lines(out.String()) {
+		var entry map[string]any
+		r.NoError(json.Unmarshal([]byte(line), &entry))
+		entries = append(entries, entry)
+	}
+	// first 2, then every 3rd: counts 5 and 8
+	r.Len(entries, 5)
+	a.Equal("t", entries[0]["retry"].(map[string]any)["table"])
+	a.Equal("other message", entries[4][slog.MessageKey])
+
+	out.Reset()
+	sampling.(*samplingHandler).s.summarize()
+	var summary map[string]any
+	r.NoError(json.Unmarshal(out.Bytes(), &summary))
+	a.Equal("suppressed log records", summary[slog.MessageKey])
+	a.Equal("query failed", summary["suppressed_msg"])
+	a.EqualValues(6, summary["suppressed"])
+	a.NotContains(summary, "dependency")
+	a.NotContains(summary, "attempt")
+	a.NotContains(summary, "retry")
+	a.Equal(slog.LevelError.String(), summary[slog.LevelKey])
+}
+
+func TestNewSamplingHandler_Disabled(t *testing.T) {
+	h := slog.DiscardHandler
+	assert.Equal(t, h, NewSamplingHandler(h, SamplingOptions{Interval: time.Second}))
+}
@@ -1,8 +1,11 @@
 package logutil
 
 import (
+	"encoding/json"
 	"errors"
 	"log/slog"
+	"runtime"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -35,10 +38,12 @@ func (s *Scope) Add(attrs ...slog.Attr) {
 }
 
 // Log returns a slog.Logger with the scope's current attributes, plus additional attributes.
+// The logger's level can be overridden by the scope's group name via LevelSpec.
 func (s *Scope) Log(log *slog.Logger, attrs ...slog.Attr) *slog.Logger {
 	sAttrs := generic.AnySlice(s.concat(attrs))
 	if s.group != "" {
-		return log.With(slog.Group(s.group, sAttrs...))
+		log = log.With(slog.Group(s.group, sAttrs...))
+		return slog.New(&scopeLevelHandler{next: log.Handler(), scope: s.group})
 	} else {
 		return log.With(sAttrs...)
 	}
@@ -47,13 +52,13 @@ func (s *Scope) Log(log *slog.Logger, attrs ...slog.Attr) *slog.Logger {
 // New creates a new error with the given message and attributes.
 // The error inherits the scope's current attributes.
 func (s *Scope) New(msg string, attrs ...slog.Attr) error {
-	return &scopedError{err: errors.New(msg), group: s.group, attrs: s.concat(attrs)}
+	return newScopedError(errors.New(msg), "", s.group, s.concat(attrs))
 }
 
 // Err wraps an error to propagate the scope's current attributes, plus additional attributes.
 // The inner error should not be nil.
 func (s *Scope) Err(err error, msg string, attrs ...slog.Attr) error {
-	return &scopedError{err: err, msg: msg, group: s.group, attrs: s.concat(attrs)}
+	return newScopedError(err, msg, s.group, s.concat(attrs))
 }
 
 func (s *Scope) concat(attrs []slog.Attr) []slog.Attr {
@@ -70,14 +75,41 @@ func NewError(err error, msg string, attrs ...slog.Attr) error {
 		// error was wrapped. A mostly unique message should identify the current operation.
 		panic("NewError: msg must not be empty, please describe what caused the error")
 	}
-	return &scopedError{err: err, msg: msg, attrs: attrs}
+	return newScopedError(err, msg, "", attrs)
 }
 
+// ErrorStacks makes NewError, Scope.New and Scope.Err capture their caller's stack. The stack of a
+// single error is captured by passing a Stack attribute instead, e.g. NewError(err, "msg", Stack(0)).
+// Only program counters are recorded, which are symbolized when the error is logged. The innermost
+// stack of an error chain is logged under StackKey. It is set by Config.InstallForProcess.
+var ErrorStacks = false
+
+// ErrorStackDepth limits the number of frames captured by ErrorStacks.
+var ErrorStackDepth = 32
+
 type scopedError struct {
 	err   error
 	msg   string
 	group string
 	attrs []slog.Attr
+	stack []uintptr
+}
+
+func newScopedError(err error, msg, group string, attrs []slog.Attr) *scopedError {
+	e := &scopedError{err: err, msg: msg, group: group}
+	for _, attr := range attrs {
+		if stack, ok := attr.Value.Any().(stackValue); ok && attr.Key == StackKey {
+			e.stack = stack.pcs
+		} else {
+			e.attrs = append(e.attrs, attr)
+		}
+	}
+	if e.stack == nil && ErrorStacks {
+		// skip newScopedError and its exported caller
+		pcs := make([]uintptr, ErrorStackDepth)
+		e.stack = pcs[:runtime.Callers(3, pcs)]
+	}
+	return e
 }
 
 // Error returns one of three formats, depending on whether msg and err are set:
@@ -86,32 +118,57 @@ type scopedError struct {
 //   - <err> [with <attrib1=value> [<attrib2=value> ...]]
 //   - scoped error [with <attrib1=value> [<attrib2=value> ...]]
 func (e scopedError) Error() string {
+	return e.format(e.attrString(), error.Error)
+}
+
+// format renders the error with the given attribute string, and renders wrapped errors via errMsg.
+func (e scopedError) format(attrString string, errMsg func(error) string) string {
 	var sb strings.Builder
 	if e.msg != "" {
 		sb.WriteString(e.msg)
 	} else if e.err != nil {
-		sb.WriteString(e.err.Error())
+		sb.WriteString(errMsg(e.err))
 	} else {
 		sb.WriteString("scoped error")
 	}
-	if len(e.attrs) > 0 {
-		sb.WriteString(" with ")
-		for i, attr := range e.attrs {
-			if i > 0 {
-				sb.WriteRune(' ')
-			}
-			sb.WriteString(attr.Key)
-			sb.WriteRune('=')
-			if j, ok := attr.Value.Any().(jsonAttr); ok {
-				sb.Write(j)
-			} else {
-				sb.WriteString(strconv.Quote(attr.Value.String()))
-			}
-		}
-	}
+	sb.WriteString(attrString)
 	if e.msg != "" && e.err != nil {
 		sb.WriteString(": ")
-		sb.WriteString(e.err.Error())
+		sb.WriteString(errMsg(e.err))
+	}
+	return sb.String()
+}
+
+// attrString formats the error's attributes as " with <attrib1=value> ...", or returns an empty
+// string if there are none. Values are redacted like by redactHandler, including group members
+// and keys of JSON attributes.
+func (e scopedError) attrString() string {
+	if len(e.attrs) == 0 {
+		return ""
+	}
+	var groups []string
+	if e.group != "" {
+		groups = []string{e.group}
+	}
+	var sb strings.Builder
+	sb.WriteString(" with ")
+	for i, attr := range e.attrs {
+		if i > 0 {
+			sb.WriteRune(' ')
+		}
+		_, isJSON := attr.Value.Any().(jsonAttr)
+		attr, _ = redactAttr(groups, attr)
+		sb.WriteString(attr.Key)
+		sb.WriteRune('=')
+		value := attr.Value.Resolve()
+		if j, ok := attr.Value.Any().(jsonAttr); ok {
+			sb.Write(j) // unchanged by redactAttr
+		} else if isJSON && value.Kind() == slog.KindGroup {
+			j, _ := json.Marshal(attrsMap(append(slices.Clip(groups), attr.Key), value.Group()))
+			sb.Write(j)
+		} else {
+			sb.WriteString(strconv.Quote(value.String()))
+		}
 	}
 	return sb.String()
 }
@@ -120,24 +177,98 @@ func (e scopedError) Unwrap() error {
 	return e.err
 }
 
-// Destructure recursively extracts attributes from an error chain with scopedError entries.
-// The error chain is modified!
-// Only the first chain with a scopedError is processed for error trees created via errors.Join.
-func Destructure(err error) (attrs []slog.Attr) {
-	curr := err
-	for {
-		var sErr *scopedError
-		if errors.As(curr, &sErr) {
+// loggedError is an error as logged by scopedErrorHandler. Its message omits the attributes of
+// scopedError entries, because they are logged separately via Destructure.
+type loggedError struct {
+	err error
+	msg string
+}
+
+func newLoggedError(err error) loggedError {
+	return loggedError{err: err, msg: loggedMessage(err)}
+}
+
+// loggedMessage renders err like Error while walking its tree, except that scopedError entries
+// omit their attributes. Other errors, e.g. of fmt.Errorf, embed the messages of the errors that
+// they wrap verbatim, which are replaced by their logged message.
+func loggedMessage(err error) string {
+	var wrapped []error
+	switch x := err.(type) {
+	case *scopedError:
+		return x.format("", loggedMessage)
+	case interface{ Unwrap() error }:
+		wrapped = []error{x.Unwrap()}
+	case interface{ Unwrap() []error }:
+		wrapped = x.Unwrap()
+	}
+	msg := err.Error()
+	for _, next := range wrapped {
+		if next == nil {
+			continue
+		}
+		if nextMsg, logged := next.Error(), loggedMessage(next); logged != nextMsg {
+			msg = strings.Replace(msg, nextMsg, logged, 1)
+		}
+	}
+	return msg
+}
+
+func (e loggedError) Error() string {
+	return e.msg
+}
+
+func (e loggedError) Unwrap() error {
+	return e.err
+}
+
+// errorStack returns the innermost stack captured along err's chain.
+func errorStack(err error) (stack []uintptr) {
+	for err != nil {
+		if sErr, ok := err.(*scopedError); ok && sErr.stack != nil {
+			stack = sErr.stack
+		}
+		err = errors.Unwrap(err)
+	}
+	return stack
+}
+
+// Destructure recursively extracts attributes from scopedError entries of an error tree. The
+// attributes along the error's chain are returned as-is, or grouped by their scope. Branches of
+// errors.Join, or of fmt.Errorf with multiple %w, are returned as group "error.causes" with an
+// indexed group per branch, which holds the branch's message and attributes. Since slog has no
+// list values, branch i is logged as "error.causes.i" rather than "error.causes[i]", i.e. as
+// object with keys "0", "1", etc. in JSON formats.
+// The error is not modified, i.e. logging it twice yields the same attributes. Log messages
+// render the error without the extracted attributes.
+func Destructure(err error) []slog.Attr {
+	return destructure(err, ErrKey+".causes")
+}
+
+func destructure(err error, causesKey string) (attrs []slog.Attr) {
+	for err != nil {
+		if sErr, ok := err.(*scopedError); ok {
 			if sErr.group != "" {
 				attrs = append(attrs, slog.Group(sErr.group, generic.AnySlice(sErr.attrs)...))
 			} else {
 				attrs = append(attrs, sErr.attrs...)
 			}
-			sErr.attrs = nil
-			curr = sErr.Unwrap()
-		} else {
-			break
+		}
+		switch x := err.(type) {
+		case interface{ Unwrap() error }:
+			err = x.Unwrap()
+		case interface{ Unwrap() []error }:
+			var causes []any
+			for i, branch := range x.Unwrap() {
+				if branch == nil {
+					continue
+				}
+				members := append([]slog.Attr{slog.String("message", newLoggedError(branch).msg)}, destructure(branch, "causes")...)
+				causes = append(causes, slog.Group(strconv.Itoa(i), generic.AnySlice(members)...))
+			}
+			return append(attrs, slog.Group(causesKey, causes...))
+		default:
+			return attrs
 		}
 	}
-	return
+	return attrs
 }
@@ -0,0 +1,154 @@
+package mainutil
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/authenticvision/util-go/bsize"
+	"github.com/authenticvision/util-go/buildinfo"
+	"github.com/authenticvision/util-go/httpp"
+	"github.com/authenticvision/util-go/logutil"
+)
+
+var startTime = time.Now()
+
+// AdminHandler serves operational endpoints on ServerConfig.AdminAddr. They reveal internals of
+// the process, so the admin address must not be reachable from outside the cluster.
+//
+//   - /debug/pprof/ serves net/http/pprof profiles, e.g. /debug/pprof/goroutine?debug=1 lists
+//     goroutines grouped by stack
+//   - /debug/runtime summarizes goroutine count and heap usage as JSON
+//   - /buildinfo serves buildinfo.Handler
+//   - /loglevel reads the process log levels via GET, and changes them via PUT with a
+//     logutil.LevelSpec as plain text body, e.g. "DEBUG" or "INFO,kafka=DEBUG"
+//
+// Responses are not compressed by httpmw.NewCompressionMiddleware, because pprof profiles are
+// gzipped already and the remaining endpoints are small.
+func AdminHandler() httpp.Handler {
+	mux := httpp.NewServeMux()
+	mux.Handle("GET /debug/pprof/", httpp.Adapt(http.HandlerFunc(pprof.Index)))
+	mux.Handle("GET /debug/pprof/cmdline", httpp.Adapt(http.HandlerFunc(pprof.Cmdline)))
+	mux.Handle("GET /debug/pprof/profile", httpp.Adapt(http.HandlerFunc(pprof.Profile)))
+	mux.Handle("GET /debug/pprof/symbol", httpp.Adapt(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("POST /debug/pprof/symbol", httpp.Adapt(http.HandlerFunc(pprof.Symbol)))
+	mux.Handle("GET /debug/pprof/trace", httpp.Adapt(http.HandlerFunc(pprof.Trace)))
+	mux.HandleFunc("GET /debug/runtime", serveRuntimeSummary)
+	mux.Handle("GET /buildinfo", buildinfo.Handler)
+	mux.HandleFunc("GET /loglevel", serveLogLevel)
+	mux.HandleFunc("PUT /loglevel", setLogLevel)
+	return httpp.HandlerFunc(func(w http.ResponseWriter, r *http.Request) error {
+		httpp.DisableCompression(w)
+		return mux.ServeErrHTTP(w, r)
+	})
+}
+
+type logLevelResponse struct {
+	Level  string            `json:"level"`
+	Scopes map[string]string `json:"scopes"`
+}
+
+func serveLogLevel(w http.ResponseWriter, r *http.Request) error {
+	spec := logutil.CurrentLevelSpec()
+	resp := logLevelResponse{Level: spec.Default.String(), Scopes: make(map[string]string)}
+	for scope, level := range spec.Scopes {
+		resp.Scopes[scope] = level.String()
+	}
+	return httpp.JSON(w, resp)
+}
+
+func setLogLevel(w http.ResponseWriter, r *http.Request) error {
+	body, err := io.ReadAll(io.LimitReader(r.Body, 4096))
+	if err != nil {
+		return httpp.BadRequest(err, "failed to read request body")
+	}
+	var spec logutil.LevelSpec
+	if err := spec.UnmarshalText(body); err != nil {
+		return httpp.BadRequest(err, "expected level spec like INFO,kafka=DEBUG")
+	}
+	logutil.SetLevelSpec(logutil.FromContext(r.Context()), spec)
+	return serveLogLevel(w, r)
+}
+
+type runtimeSummary struct {
+	GoVersion  string      `json:"go_version"`
+	GOMAXPROCS int         `json:"gomaxprocs"`
+	Uptime     string      `json:"uptime"`
+	Goroutines int         `json:"goroutines"`
+	Heap       heapSummary `json:"heap"`
+}
+
+type heapSummary struct {
+	Alloc         string  `json:"alloc"`
+	InUse         string  `json:"in_use"`
+	Sys           string  `json:"sys"`
+	Objects       uint64  `json:"objects"`
+	NumGC         uint32  `json:"num_gc"`
+	LastGC        string  `json:"last_gc,omitempty"`
+	GCCPUFraction float64 `json:"gc_cpu_fraction"`
+}
+
+func serveRuntimeSummary(w http.ResponseWriter, r *http.Request) error {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem) // stops the world briefly, which is fine for occasional debugging
+	summary := runtimeSummary{
+		GoVersion:  runtime.Version(),
+		GOMAXPROCS: runtime.GOMAXPROCS(0),
+		Uptime:     time.Since(startTime).Round(time.Second).String(),
+		Goroutines: runtime.NumGoroutine(),
+		Heap: heapSummary{
+			Alloc:         bsize.Bytes(mem.HeapAlloc).String(),
+			InUse:         bsize.Bytes(mem.HeapInuse).String(),
+			Sys:           bsize.Bytes(mem.HeapSys).String(),
+			Objects:       mem.HeapObjects,
+			NumGC:         mem.NumGC,
+			GCCPUFraction: mem.GCCPUFraction,
+		},
+	}
+	if mem.LastGC != 0 {
+		summary.Heap.LastGC = time.Since(time.Unix(0, int64(mem.LastGC))).Round(time.Millisecond).String() + " ago"
+	}
+	return httpp.JSON(w, summary)
+}
+
+// serveAdmin serves AdminHandler on addr until ctx is canceled. Unlike ListenAndServe, requests
+// bypass the middlewares of the main server, and the server stops immediately without taking
+// part in health checks, so that debugging does not interfere with the service.
+func serveAdmin(ctx context.Context, addr string) error {
+	log := logutil.FromContext(ctx)
+	l, addr, err := listen(log, addr)
+	if err != nil {
+		return err
+	}
+	defer closeListener(log, l)
+
+	handler := AdminHandler()
+	server := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if err := handler.ServeErrHTTP(w, r); err != nil {
+				httpp.WriteErrorFor(w, r, err)
+			}
+		}),
+		BaseContext: func(net.Listener) context.Context {
+			return context.WithoutCancel(ctx) // retains the logger for /loglevel
+		},
+	}
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- server.Serve(l)
+	}()
+
+	select {
+	case <-ctx.Done():
+		_ = server.Close()
+		return ctx.Err()
+	case err := <-serveErr:
+		return fmt.Errorf("serve: %w", err)
+	}
+}
@@ -0,0 +1,98 @@
+package mainutil
+
+import (
+	"context"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/authenticvision/util-go/health"
+	"github.com/authenticvision/util-go/httpmw"
+	"github.com/authenticvision/util-go/logutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminHandler_LogLevel(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	prev := logutil.ProcessLevel.Level()
+	t.Cleanup(func() { logutil.ProcessLevel.Set(prev) })
+	logutil.ProcessLevel.Set(slog.LevelInfo)
+
+	handler := AdminHandler()
+	ctx := logutil.WithLogContext(t.Context(), slog.New(slog.DiscardHandler))
+
+	req := httptest.NewRequestWithContext(ctx, http.MethodPut, "/loglevel", strings.NewReader("trace\n"))
+	w := httptest.NewRecorder()
+	r.NoError(handler.ServeErrHTTP(w, req))
+	a.JSONEq(`{"level":"TRACE","scopes":{}}`, w.Body.String())
+	a.Equal(logutil.LevelTrace, logutil.ProcessLevel.Level())
+
+	req = httptest.NewRequestWithContext(ctx, http.MethodPut, "/loglevel", strings.NewReader("verbose"))
+	err := handler.ServeErrHTTP(httptest.NewRecorder(), req)
+	a.Error(err)
+	a.Equal(logutil.LevelTrace, logutil.ProcessLevel.Level())
+
+	req = httptest.NewRequestWithContext(ctx, http.MethodGet, "/loglevel", nil)
+	w = httptest.NewRecorder()
+	r.NoError(handler.ServeErrHTTP(w, req))
+	a.JSONEq(`{"level":"TRACE","scopes":{}}`, w.Body.String())
+
+	req = httptest.NewRequestWithContext(ctx, http.MethodPut, "/loglevel", strings.NewReader("kafka=debug"))
+	w = httptest.NewRecorder()
+	r.NoError(handler.ServeErrHTTP(w, req))
+	a.JSONEq(`{"level":"TRACE","scopes":{"kafka":"DEBUG"}}`, w.Body.String())
+	logutil.SetLevelSpec(slog.New(slog.DiscardHandler), logutil.LevelSpec{})
+}
+
+func TestAdminHandler_Uncompressed(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	handler := httpmw.Chain(AdminHandler(), httpmw.NewCompressionMiddleware())
+	req := httptest.NewRequest(http.MethodGet, "/debug/pprof/heap", nil)
+	req.Header.Set("Accept-Encoding", "gzip, zstd")
+	w := httptest.NewRecorder()
+	r.NoError(handler.ServeErrHTTP(w, req))
+	a.Equal(http.StatusOK, w.Code)
+	a.Empty(w.Header().Get("Content-Encoding"))
+}
+
+func TestServeAdmin(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	socket := filepath.Join(t.TempDir(), "admin.sock")
+	ctx, cancel := context.WithCancel(logutil.WithLogContext(t.Context(), slog.New(slog.DiscardHandler)))
+	done := make(chan error, 1)
+	go func() { done <- serveAdmin(ctx, "unix:"+socket) }()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}}
+	var resp *http.Response
+	r.Eventually(func() bool {
+		var err error
+		resp, err = client.Get("http://admin/loglevel/missing")
+		return err == nil
+	}, time.Second, time.Millisecond)
+	_ = resp.Body.Close()
+	a.Equal(http.StatusNotFound, resp.StatusCode)
+
+	cancel()
+	a.ErrorIs(<-done, context.Canceled)
+	a.NotEqual(health.StatusShuttingDown, health.Default.Ready(t.Context()).Status)
+	_, err := os.Stat(socket)
+	a.True(os.IsNotExist(err))
+}
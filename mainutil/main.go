@@ -83,7 +83,8 @@ func RootCommand[T LogConfigEmbedder](
 
 func setupContext[T LogConfigEmbedder](cfg *T, cmd *cobra.Command, args []string) error {
 	// logutil replaces slog.Default() and the older log package's output
-	if err := (*cfg).LogConfigEmbed().Log.InstallForProcess(); err != nil {
+	logCfg := (*cfg).LogConfigEmbed().Log
+	if err := logCfg.InstallForProcess(); err != nil {
 		slog.Error("error installing log handler", logutil.Err(err))
 		os.Exit(1)
 	}
@@ -91,6 +92,7 @@ func setupContext[T LogConfigEmbedder](cfg *T, cmd *cobra.Command, args []string
 
 	// replaced log handler must be applied to current and possibly separate root command
 	ctx := cmd.Context()
+	logCfg.WatchLevel(ctx, log)
 	ctx = logutil.WithLogContext(ctx, log)
 	cmd.SetContext(ctx)
 	if rootCmd := cmd.Root(); rootCmd != cmd {
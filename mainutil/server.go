@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io/fs"
 	"log/slog"
 	"net"
 	"net/http"
@@ -11,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/authenticvision/util-go/health"
 	"github.com/authenticvision/util-go/httpmw"
 	"github.com/authenticvision/util-go/httpp"
 	"github.com/authenticvision/util-go/logutil"
@@ -27,17 +29,51 @@ type ServerMain[T any] func(cfg *T, cmd *cobra.Command, args []string) (httpp.Ha
 // setup or teardown. It should wrap a function that returns an httpp.Handler for mainutil.Main.
 func Server[T ServerConfigEmbedder](serverMain ServerMain[T], opts ...ServerOption) nicecmd.Hook[T] {
 	return func(cfg *T, cmd *cobra.Command, args []string) error {
-		addr := (*cfg).ServerConfigEmbed().BindAddr
+		serverCfg := (*cfg).ServerConfigEmbed()
 		if handler, err := serverMain(cfg, cmd, args); err != nil {
 			return fmt.Errorf("server main: %w", err)
-		} else if err := ListenAndServe(cmd.Context(), addr, handler, opts...); err != nil {
-			return fmt.Errorf("listen and serve %q: %w", addr, err)
 		} else {
-			return nil
+			return serve(cmd.Context(), serverCfg, handler, opts...)
 		}
 	}
 }
 
+// serve runs the main server and, if configured, the admin server. The admin server is stopped
+// after the main server, so that it remains available for debugging a slow shutdown.
+func serve(ctx context.Context, cfg ServerConfig, handler httpp.Handler, opts ...ServerOption) error {
+	if cfg.AdminAddr == "" {
+		if err := ListenAndServe(ctx, cfg.BindAddr, handler, opts...); err != nil {
+			return fmt.Errorf("listen and serve %q: %w", cfg.BindAddr, err)
+		}
+		return nil
+	}
+
+	mainCtx, mainCancel := context.WithCancel(ctx)
+	defer mainCancel()
+	adminCtx, adminCancel := context.WithCancel(context.WithoutCancel(ctx))
+	defer adminCancel()
+	adminErr := make(chan error, 1)
+	go func() {
+		err := serveAdmin(adminCtx, cfg.AdminAddr)
+		if err != nil && !errors.Is(err, context.Canceled) {
+			mainCancel() // e.g. admin address in use, don't keep running without it
+			err = fmt.Errorf("listen and serve admin %q: %w", cfg.AdminAddr, err)
+		} else {
+			err = nil
+		}
+		adminErr <- err
+	}()
+
+	err := ListenAndServe(mainCtx, cfg.BindAddr, handler, opts...)
+	adminCancel()
+	if adminErr := <-adminErr; adminErr != nil {
+		return adminErr
+	} else if err != nil {
+		return fmt.Errorf("listen and serve %q: %w", cfg.BindAddr, err)
+	}
+	return nil
+}
+
 type ServerOption func(*http.Server)
 
 // WithPlainHTTP2 enables plain-text HTTP 2 in addition to HTTP 1, e.g. for a gRPC server.
@@ -60,27 +96,25 @@ func WithOnShutdown(f func()) ServerOption {
 	}
 }
 
+// WithErrorRenderer replaces httpp.DefaultErrorRenderer for errors returned by the handler.
+func WithErrorRenderer(renderer httpp.ErrorRenderer) ServerOption {
+	return func(server *http.Server) {
+		next := server.Handler
+		server.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(httpp.WithErrorRenderer(r.Context(), renderer)))
+		})
+	}
+}
+
+// ListenAndServe serves handler until ctx is canceled. Shutdown then first fails readiness probes
+// of health.Default, and gives active requests a grace period of ShutdownTimeout to complete.
 func ListenAndServe(ctx context.Context, addr string, handler httpp.Handler, opts ...ServerOption) error {
 	log := logutil.FromContext(ctx)
-
-	network := "tcp"
-	var ok bool
-	if addr, ok = strings.CutPrefix(addr, "unix:"); ok {
-		network = "unix"
-		defer func() {
-			if err := os.Remove(addr); err != nil {
-				log.Error("failed to remove unix socket file", logutil.Err(err))
-			}
-		}()
-	}
-	l, err := net.Listen(network, addr)
+	l, addr, err := listen(log, addr)
 	if err != nil {
-		return fmt.Errorf("listen %q: %w", addr, err)
+		return err
 	}
-	//goland:noinspection HttpUrlsUsage
-	log.Info("listening",
-		slog.String("bind_addr", addr),
-		slog.String("link", fmt.Sprintf("http://%s", addr)))
+	defer closeListener(log, l)
 
 	reqCtx, reqCancel := context.WithCancel(context.WithoutCancel(ctx))
 	defer reqCancel()
@@ -109,6 +143,9 @@ func ListenAndServe(ctx context.Context, addr string, handler httpp.Handler, opt
 
 	select {
 	case <-ctx.Done():
+		// Fail readiness probes, so that no new requests are routed to this instance.
+		health.BeginShutdown()
+
 		// Notify active requests to terminate after grace period.
 		time.AfterFunc(ShutdownTimeout, reqCancel)
 
@@ -136,12 +173,41 @@ func ListenAndServe(ctx context.Context, addr string, handler httpp.Handler, opt
 	}
 }
 
+// listen listens on addr, which is a unix socket path if prefixed with "unix:". The listener must
+// be passed to closeListener, which removes the unix socket file.
+func listen(log *slog.Logger, addr string) (net.Listener, string, error) {
+	network := "tcp"
+	var ok bool
+	if addr, ok = strings.CutPrefix(addr, "unix:"); ok {
+		network = "unix"
+	}
+	l, err := net.Listen(network, addr)
+	if err != nil {
+		return nil, addr, fmt.Errorf("listen %q: %w", addr, err)
+	}
+	//goland:noinspection HttpUrlsUsage
+	log.Info("listening",
+		slog.String("bind_addr", addr),
+		slog.String("link", fmt.Sprintf("http://%s", addr)))
+	return l, addr, nil
+}
+
+func closeListener(log *slog.Logger, l net.Listener) {
+	if addr, ok := l.Addr().(*net.UnixAddr); ok {
+		// usually removed by the listener already when the server closes it
+		if err := os.Remove(addr.Name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Error("failed to remove unix socket file", logutil.Err(err))
+		}
+	}
+}
+
 type ServerConfigEmbedder interface {
 	ServerConfigEmbed() ServerConfig
 }
 
 type ServerConfig struct {
-	BindAddr string `usage:"bind address for HTTP connections. to use a unix socket, prefix with 'unix:'"`
+	BindAddr  string `usage:"bind address for HTTP connections. to use a unix socket, prefix with 'unix:'"`
+	AdminAddr string `usage:"optional bind address for the admin server with pprof and log level endpoints. must not be public"`
 }
 
 func (c ServerConfig) ServerConfigEmbed() ServerConfig {
@@ -0,0 +1,47 @@
+package tracectx
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// FromHTTP continues the trace of an inbound HTTP request, see Continue.
+func FromHTTP(ctx context.Context, header http.Header) context.Context {
+	return Continue(ctx, header.Get(TraceparentHeader), strings.Join(header.Values(TracestateHeader), ","))
+}
+
+// InjectHTTP sets the headers of an outbound HTTP request to propagate the span of ctx, if any.
+func InjectHTTP(ctx context.Context, header http.Header) {
+	sc, ok := FromContext(ctx)
+	if !ok || !sc.IsValid() {
+		return
+	}
+	header.Set(TraceparentHeader, sc.Traceparent())
+	if sc.State != "" {
+		header.Set(TracestateHeader, sc.State)
+	} else {
+		header.Del(TracestateHeader)
+	}
+}
+
+// Transport propagates the span of each request's context to the server. Requests that carry a
+// traceparent header already are sent as-is. A nil next defaults to http.DefaultTransport.
+func Transport(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		if _, ok := FromContext(r.Context()); ok && r.Header.Get(TraceparentHeader) == "" {
+			r = r.Clone(r.Context()) // RoundTrippers must not modify the request
+			InjectHTTP(r.Context(), r.Header)
+		}
+		return next.RoundTrip(r)
+	})
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
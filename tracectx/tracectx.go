@@ -0,0 +1,189 @@
+// Package tracectx propagates W3C Trace Context (https://www.w3.org/TR/trace-context/) across
+// services, so that their logs can be correlated by trace ID. It does not record spans, i.e. each
+// request handled by a service is a span that is only visible through its logs.
+package tracectx
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/authenticvision/util-go/logutil"
+)
+
+// Header names as per W3C Trace Context. They are also used for gRPC metadata and Kafka headers.
+const (
+	TraceparentHeader = "traceparent"
+	TracestateHeader  = "tracestate"
+)
+
+// MaxStateLen limits the length of forwarded tracestate values. Entries beyond it are dropped.
+var MaxStateLen = 512
+
+// TraceID identifies a trace, i.e. all spans caused by the same origin request.
+type TraceID [16]byte
+
+func (id TraceID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid reports whether id is not all zeros, which is invalid as per W3C Trace Context.
+func (id TraceID) IsValid() bool {
+	return id != TraceID{}
+}
+
+// SpanID identifies a span within a trace.
+type SpanID [8]byte
+
+func (id SpanID) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+// IsValid reports whether id is not all zeros, which is invalid as per W3C Trace Context.
+func (id SpanID) IsValid() bool {
+	return id != SpanID{}
+}
+
+// Flags are trace flags of a traceparent.
+type Flags byte
+
+// FlagSampled indicates that the caller may have recorded its span. It is forwarded as-is.
+const FlagSampled Flags = 0x01
+
+// SpanContext is the propagated state of a span.
+type SpanContext struct {
+	TraceID TraceID
+	SpanID  SpanID
+	Flags   Flags
+	State   string // tracestate, forwarded as-is
+}
+
+// New starts a new trace.
+func New() SpanContext {
+	var sc SpanContext
+	for !sc.TraceID.IsValid() {
+		_, _ = rand.Read(sc.TraceID[:])
+	}
+	return sc.Child()
+}
+
+// Child returns a span of the same trace with a new span ID.
+func (sc SpanContext) Child() SpanContext {
+	sc.SpanID = SpanID{}
+	for !sc.SpanID.IsValid() {
+		_, _ = rand.Read(sc.SpanID[:])
+	}
+	return sc
+}
+
+// IsValid reports whether trace and span ID are valid.
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats sc as traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, byte(sc.Flags))
+}
+
+// LogAttrs returns trace and span ID under logutil.TraceIDKey and logutil.SpanIDKey.
+func (sc SpanContext) LogAttrs() []slog.Attr {
+	return []slog.Attr{
+		slog.String(logutil.TraceIDKey, sc.TraceID.String()),
+		slog.String(logutil.SpanIDKey, sc.SpanID.String()),
+	}
+}
+
+// Parse parses traceparent and tracestate header values. The tracestate is optional, and it is
+// truncated to MaxStateLen.
+func Parse(traceparent, tracestate string) (SpanContext, error) {
+	var sc SpanContext
+	// version "00" has exactly four fields, later versions may append fields
+	const v0Len = 55
+	if len(traceparent) < v0Len || (len(traceparent) > v0Len && traceparent[v0Len] != '-') {
+		return sc, errors.New("malformed traceparent")
+	}
+	fields := strings.Split(traceparent[:v0Len], "-")
+	if len(fields) != 4 || len(fields[0]) != 2 || len(fields[1]) != 32 || len(fields[2]) != 16 || len(fields[3]) != 2 {
+		return sc, errors.New("malformed traceparent")
+	}
+	var version [1]byte
+	var flags [1]byte
+	if err := decodeHex(version[:], fields[0]); err != nil {
+		return sc, fmt.Errorf("traceparent version: %w", err)
+	} else if version[0] == 0xff || (version[0] == 0 && len(traceparent) != v0Len) {
+		return sc, fmt.Errorf("unsupported traceparent version %s", fields[0])
+	}
+	if err := decodeHex(sc.TraceID[:], fields[1]); err != nil {
+		return sc, fmt.Errorf("trace ID: %w", err)
+	}
+	if err := decodeHex(sc.SpanID[:], fields[2]); err != nil {
+		return sc, fmt.Errorf("parent ID: %w", err)
+	}
+	if err := decodeHex(flags[:], fields[3]); err != nil {
+		return sc, fmt.Errorf("trace flags: %w", err)
+	}
+	if !sc.IsValid() {
+		return sc, errors.New("traceparent has zero trace or parent ID")
+	}
+	sc.Flags = Flags(flags[0]) & FlagSampled // other flags are unknown to version 00
+	sc.State = truncateState(tracestate)
+	return sc, nil
+}
+
+// decodeHex decodes lowercase hex only, as required by W3C Trace Context.
+func decodeHex(dst []byte, s string) error {
+	if strings.ToLower(s) != s {
+		return errors.New("uppercase hex")
+	}
+	_, err := hex.Decode(dst, []byte(s))
+	return err
+}
+
+func truncateState(state string) string {
+	var sb strings.Builder
+	for _, entry := range strings.Split(state, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if sb.Len() > 0 {
+			if sb.Len()+1+len(entry) > MaxStateLen {
+				break
+			}
+			sb.WriteByte(',')
+		} else if len(entry) > MaxStateLen {
+			break
+		}
+		sb.WriteString(entry)
+	}
+	return sb.String()
+}
+
+type spanContextKey struct{}
+
+// WithSpanContext attaches sc to ctx. Its IDs are logged via logutil.ContextAttrs.
+func WithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	ctx = context.WithValue(ctx, spanContextKey{}, sc)
+	return logutil.WithContextAttrs(ctx, sc.LogAttrs()...)
+}
+
+// FromContext returns the span context attached to ctx.
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// Continue attaches a span to ctx that continues the trace of an inbound request. A new trace is
+// started if traceparent is missing or invalid, in which case tracestate is discarded.
+func Continue(ctx context.Context, traceparent, tracestate string) context.Context {
+	parent, err := Parse(traceparent, tracestate)
+	if err != nil {
+		return WithSpanContext(ctx, New())
+	}
+	return WithSpanContext(ctx, parent.Child())
+}
@@ -0,0 +1,108 @@
+package tracectx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/authenticvision/util-go/logutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	const (
+		traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+		spanID  = "00f067aa0ba902b7"
+	)
+	tests := []struct {
+		name        string
+		traceparent string
+		tracestate  string
+		wantErr     bool
+		wantFlags   Flags
+		wantState   string
+	}{
+		{name: "sampled", traceparent: "00-" + traceID + "-" + spanID + "-01", tracestate: "congo=t61rcWkgMzE", wantFlags: FlagSampled, wantState: "congo=t61rcWkgMzE"},
+		{name: "not sampled", traceparent: "00-" + traceID + "-" + spanID + "-00"},
+		{name: "future version", traceparent: "cc-" + traceID + "-" + spanID + "-09-what-the-future-will-be", wantFlags: FlagSampled},
+		{name: "tracestate list", traceparent: "00-" + traceID + "-" + spanID + "-00", tracestate: "rojo=00f067aa0ba902b7, ,congo=t61rcWkgMzE", wantState: "rojo=00f067aa0ba902b7,congo=t61rcWkgMzE"},
+		{name: "long tracestate", traceparent: "00-" + traceID + "-" + spanID + "-00", tracestate: "a=1," + strings.Repeat("b", MaxStateLen), wantState: "a=1"},
+		{name: "empty", traceparent: "", wantErr: true},
+		{name: "version 00 with extra fields", traceparent: "00-" + traceID + "-" + spanID + "-01-x", wantErr: true},
+		{name: "version ff", traceparent: "ff-" + traceID + "-" + spanID + "-01", wantErr: true},
+		{name: "uppercase", traceparent: "00-" + strings.ToUpper(traceID) + "-" + spanID + "-01", wantErr: true},
+		{name: "zero trace ID", traceparent: "00-" + strings.Repeat("0", 32) + "-" + spanID + "-01", wantErr: true},
+		{name: "zero parent ID", traceparent: "00-" + traceID + "-" + strings.Repeat("0", 16) + "-01", wantErr: true},
+		{name: "not hex", traceparent: "00-" + traceID + "-" + spanID + "-0g", wantErr: true},
+		{name: "wrong separator", traceparent: "00_" + traceID + "-" + spanID + "-01", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := assert.New(t)
+			sc, err := Parse(tt.traceparent, tt.tracestate)
+			if tt.wantErr {
+				a.Error(err)
+				return
+			}
+			a.NoError(err)
+			a.Equal(traceID, sc.TraceID.String())
+			a.Equal(spanID, sc.SpanID.String())
+			a.Equal(tt.wantFlags, sc.Flags)
+			a.Equal(tt.wantState, sc.State)
+		})
+	}
+}
+
+func TestContinue(t *testing.T) {
+	a := assert.New(t)
+
+	ctx := Continue(t.Context(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", "congo=t61rcWkgMzE")
+	sc, ok := FromContext(ctx)
+	a.True(ok)
+	a.Equal("4bf92f3577b34da6a3ce929d0e0e4736", sc.TraceID.String())
+	a.NotEqual("00f067aa0ba902b7", sc.SpanID.String())
+	a.True(sc.SpanID.IsValid())
+	a.Equal(FlagSampled, sc.Flags)
+	a.Equal("congo=t61rcWkgMzE", sc.State)
+	a.Equal(sc.LogAttrs(), logutil.ContextAttrs(ctx))
+
+	child := Continue(ctx, "invalid", "congo=t61rcWkgMzE")
+	childSc, ok := FromContext(child)
+	a.True(ok)
+	a.True(childSc.IsValid())
+	a.NotEqual(sc.TraceID, childSc.TraceID)
+	a.Empty(childSc.State)
+	a.Equal(childSc.LogAttrs(), logutil.ContextAttrs(child), "context attributes of the parent span are replaced")
+}
+
+func TestTransport(t *testing.T) {
+	r := require.New(t)
+	a := assert.New(t)
+
+	var received http.Header
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r.Header
+	}))
+	t.Cleanup(srv.Close)
+	client := &http.Client{Transport: Transport(nil)}
+
+	sc := New()
+	sc.State = "congo=t61rcWkgMzE"
+	req, err := http.NewRequestWithContext(WithSpanContext(t.Context(), sc), http.MethodGet, srv.URL, nil)
+	r.NoError(err)
+	resp, err := client.Do(req)
+	r.NoError(err)
+	_ = resp.Body.Close()
+	a.Equal(sc.Traceparent(), received.Get(TraceparentHeader))
+	a.Equal("congo=t61rcWkgMzE", received.Get(TracestateHeader))
+	a.Empty(req.Header.Get(TraceparentHeader), "the request must not be modified")
+
+	req, err = http.NewRequestWithContext(t.Context(), http.MethodGet, srv.URL, nil)
+	r.NoError(err)
+	resp, err = client.Do(req)
+	r.NoError(err)
+	_ = resp.Body.Close()
+	a.Empty(received.Get(TraceparentHeader))
+}